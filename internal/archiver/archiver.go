@@ -0,0 +1,452 @@
+// Package archiver streams the logs of database servers into the backup
+// destination next to their backups: the binary logs of MySQL servers labeled
+// with backup.mysql.binlog=true and the WAL of postgres-physical clusters. The
+// full backups can then be rolled forward to a point in time.
+package archiver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/encryption"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+
+	"go.uber.org/zap"
+)
+
+const (
+	GlobalConfigKeyUploadInterval = "BINLOG_UPLOAD_INTERVAL"
+	DefaultUploadInterval         = time.Minute
+
+	minRestartDelay = 5 * time.Second
+	maxRestartDelay = 5 * time.Minute
+	// flushTimeout bounds the last upload after streaming stopped
+	flushTimeout = 2 * time.Minute
+	// dropSlotTimeout bounds dropping the replication slot of a removed container
+	dropSlotTimeout = 30 * time.Second
+)
+
+// source is one kind of server log an archiver streams.
+type source interface {
+	// name tells the logs apart in messages and staging directory names
+	name() string
+	keyPrefix() string
+	// command returns the process that streams the log into the staging
+	// directory, picking up after what is already staged or archived. It is
+	// called before every start.
+	command(ctx context.Context, a *archiver) (*exec.Cmd, error)
+	// states tells apart the staged files, given sorted by name
+	states(names []string) []fileState
+}
+
+type fileState int
+
+const (
+	// fileSkipped is still being written and is not uploaded yet
+	fileSkipped fileState = iota
+	// fileActive is still being written and is uploaded again whenever it grew
+	fileActive
+	// fileKept is complete and stays staged after the upload, as the stream
+	// resumes after it
+	fileKept
+	// fileComplete is complete and removed once it is uploaded
+	fileComplete
+)
+
+// newSource returns the log archived for spec, or nil if there is none.
+func newSource(spec model.BackupSpec) source {
+	switch {
+	case spec.MySQLBinlog:
+		return &binlogSource{spec: spec}
+	case spec.Type == dumper.PostgresPhysicalDumperType:
+		return &walSource{spec: spec}
+	}
+	return nil
+}
+
+// Manager runs one archiver per container whose logs are archived.
+type Manager struct {
+	mu           sync.Mutex
+	globalConfig map[string]string
+	archivers    map[string]*archiver
+}
+
+func NewManager(globalConfig map[string]string) *Manager {
+	return &Manager{globalConfig: globalConfig, archivers: make(map[string]*archiver)}
+}
+
+// Sync starts, restarts or stops the archiver of a container to match spec.
+func (m *Manager) Sync(containerID string, spec model.BackupSpec) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	src := newSource(spec)
+	existing, exists := m.archivers[containerID]
+	if exists && src != nil && sameArchive(existing.spec, spec) {
+		return
+	}
+	if exists {
+		existing.stop(true)
+		if !sameSlot(existing.spec, spec) {
+			existing.dropSlot()
+		}
+		delete(m.archivers, containerID)
+	}
+	if src == nil {
+		return
+	}
+
+	a, err := newArchiver(containerID, spec, src, m.globalConfig)
+	if err != nil {
+		logger.Log.Error("Failed to start log archiving",
+			zap.String("containerID", containerID),
+			zap.String("log", src.name()),
+			zap.Error(err),
+		)
+		return
+	}
+	a.start()
+	m.archivers[containerID] = a
+}
+
+// Remove stops the archiver of a container that is no longer backed up and
+// drops its replication slot.
+func (m *Manager) Remove(containerID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if a, exists := m.archivers[containerID]; exists {
+		a.stop(true)
+		a.dropSlot()
+		delete(m.archivers, containerID)
+	}
+}
+
+// Stop stops every archiver after shipping what it received so far. The staged
+// files are kept, so streaming resumes after them on the next start.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for containerID, a := range m.archivers {
+		a.stop(false)
+		delete(m.archivers, containerID)
+	}
+}
+
+// sameArchive reports whether an archiver started for a can keep running for b.
+func sameArchive(a, b model.BackupSpec) bool {
+	return a.Type == b.Type && a.Conn == b.Conn && a.Database == b.Database &&
+		a.MySQLAllDatabases == b.MySQLAllDatabases && a.MySQLBinlog == b.MySQLBinlog && a.PgSlot == b.PgSlot &&
+		a.Dest == b.Dest && a.Prefix == b.Prefix &&
+		a.Encrypt == b.Encrypt && a.EncryptionType == b.EncryptionType &&
+		slices.Equal(a.EncryptRecipients, b.EncryptRecipients)
+}
+
+type archiver struct {
+	containerID  string
+	spec         model.BackupSpec
+	src          source
+	backupWriter writer.BackupWriter
+	encryptor    encryption.Encryptor
+	// dir stages the files the stream writes until they are uploaded
+	dir      string
+	interval time.Duration
+	// uploaded holds the size of each staged file when it was last uploaded
+	uploaded map[string]int64
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func newArchiver(containerID string, spec model.BackupSpec, src source, globalConfig map[string]string) (*archiver, error) {
+	backupWriter, err := writer.GetWriter(spec, globalConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get writer for %s: %w", spec.Dest, err)
+	}
+	var encryptor encryption.Encryptor
+	if spec.Encrypt {
+		encryptor, err = encryption.GetEncryptor(spec, globalConfig)
+		if err != nil {
+			return nil, fmt.Errorf("encryption requested via backup.encrypt but no usable encryptor is configured: %w", err)
+		}
+	}
+
+	interval := DefaultUploadInterval
+	if value := globalConfig[GlobalConfigKeyUploadInterval]; value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			interval = d
+		} else {
+			logger.Log.Warn("Invalid BINLOG_UPLOAD_INTERVAL value, using default",
+				zap.String("value", value),
+				zap.Duration("default", DefaultUploadInterval),
+			)
+		}
+	}
+
+	scratch := globalConfig[dumper.GlobalConfigKeyScratchDir]
+	if scratch == "" {
+		scratch = os.TempDir()
+	}
+
+	return &archiver{
+		containerID:  containerID,
+		spec:         spec,
+		src:          src,
+		backupWriter: backupWriter,
+		encryptor:    encryptor,
+		dir:          filepath.Join(scratch, "label-backup-"+src.name()+"-"+writer.SanitizeKeyPart(containerID)),
+		interval:     interval,
+		uploaded:     make(map[string]int64),
+		done:         make(chan struct{}),
+	}, nil
+}
+
+func (a *archiver) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	go a.run(ctx)
+	logger.Log.Info("Started log archiving",
+		zap.String("containerID", a.containerID),
+		zap.String("log", a.src.name()),
+		zap.String("archive", a.src.keyPrefix()),
+		zap.Duration("uploadInterval", a.interval),
+	)
+}
+
+// stop waits for the last upload. removeStaged also drops the staging directory,
+// for containers whose logs are not archived there anymore.
+func (a *archiver) stop(removeStaged bool) {
+	a.cancel()
+	<-a.done
+	if removeStaged {
+		if err := os.RemoveAll(a.dir); err != nil {
+			logger.Log.Error("Failed to remove log staging directory", zap.String("path", a.dir), zap.Error(err))
+		}
+	}
+	logger.Log.Info("Stopped log archiving",
+		zap.String("containerID", a.containerID),
+		zap.String("log", a.src.name()),
+	)
+}
+
+// dropSlot drops the replication slot of a stopped WAL archiver. A slot left
+// behind makes the server keep WAL forever, so a failure is logged with the
+// slot to drop by hand.
+func (a *archiver) dropSlot() {
+	s, ok := a.src.(*walSource)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dropSlotTimeout)
+	defer cancel()
+	if err := s.dropSlot(ctx); err != nil {
+		logger.Log.Warn("Failed to drop replication slot, the server keeps WAL for it until it is dropped by hand",
+			zap.String("containerID", a.containerID),
+			zap.String("slot", slotName(s.spec)),
+			zap.Error(err),
+		)
+		return
+	}
+	logger.Log.Info("Dropped replication slot",
+		zap.String("containerID", a.containerID),
+		zap.String("slot", slotName(s.spec)),
+	)
+}
+
+// run keeps the stream running, restarting it with a growing delay when it
+// exits, e.g. because the server restarted.
+func (a *archiver) run(ctx context.Context) {
+	defer close(a.done)
+
+	delay := minRestartDelay
+	for {
+		started := time.Now()
+		err := a.stream(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > maxRestartDelay {
+			delay = minRestartDelay
+		}
+		logger.Log.Warn("Log streaming stopped, restarting",
+			zap.String("containerID", a.containerID),
+			zap.String("log", a.src.name()),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay = min(delay*2, maxRestartDelay)
+	}
+}
+
+func (a *archiver) stream(ctx context.Context) error {
+	if err := os.MkdirAll(a.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create log staging directory: %w", err)
+	}
+	// Ship what an earlier stream left behind before picking up after it
+	a.upload(ctx)
+
+	cmd, err := a.src.command(ctx, a)
+	if err != nil {
+		return err
+	}
+	var stderrBuf bytes.Buffer
+	cmd.Stderr = &stderrBuf
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", cmd.Args[0], err)
+	}
+	logger.Log.Info("Streaming logs",
+		zap.String("containerID", a.containerID),
+		zap.String("log", a.src.name()),
+		zap.String("command", cmd.Args[0]),
+	)
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.upload(ctx)
+		case err := <-exited:
+			// Ship what arrived before the stream ended
+			flushCtx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			a.upload(flushCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%s exited (stderr: %s): %w", cmd.Args[0], strings.TrimSpace(stderrBuf.String()), err)
+		}
+	}
+}
+
+// upload ships the staged files that grew since they were last uploaded, and
+// removes the complete ones once they are shipped.
+func (a *archiver) upload(ctx context.Context) {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		logger.Log.Warn("Failed to read log staging directory", zap.String("path", a.dir), zap.Error(err))
+		return
+	}
+	var files []os.FileInfo
+	var names []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			files = append(files, info)
+			names = append(names, info.Name())
+		}
+	}
+
+	states := a.src.states(names)
+	for i, info := range files {
+		name := info.Name()
+		if states[i] == fileSkipped {
+			continue
+		}
+		if a.uploaded[name] != info.Size() {
+			if err := a.uploadFile(ctx, name); err != nil {
+				logger.Log.Warn("Failed to upload log file, retrying on the next upload",
+					zap.String("containerID", a.containerID),
+					zap.String("file", name),
+					zap.Error(err),
+				)
+				return
+			}
+			a.uploaded[name] = info.Size()
+		}
+		if states[i] == fileComplete {
+			if err := os.Remove(filepath.Join(a.dir, name)); err != nil {
+				logger.Log.Warn("Failed to remove archived log file", zap.String("file", name), zap.Error(err))
+				continue
+			}
+			delete(a.uploaded, name)
+		}
+	}
+}
+
+// uploadFile gzips, optionally encrypts and writes one staged file.
+func (a *archiver) uploadFile(ctx context.Context, name string) error {
+	f, err := os.Open(filepath.Join(a.dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		gw := gzip.NewWriter(pw)
+		_, err := io.Copy(gw, f)
+		if closeErr := gw.Close(); err == nil {
+			err = closeErr
+		}
+		pw.CloseWithError(err)
+	}()
+
+	key := a.src.keyPrefix() + name + ".gz"
+	var source io.Reader = pr
+	var encryptedReader io.ReadCloser
+	if a.encryptor != nil {
+		encryptedReader, err = a.encryptor.Encrypt(ctx, pr)
+		if err != nil {
+			return fmt.Errorf("failed to start encryption: %w", err)
+		}
+		source = encryptedReader
+		key += a.encryptor.GetEncryptedExtension()
+	}
+
+	_, size, _, err := a.backupWriter.Write(ctx, key, source)
+	if encryptedReader != nil {
+		if closeErr := encryptedReader.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	logger.Log.Debug("Uploaded log file",
+		zap.String("containerID", a.containerID),
+		zap.String("object", key),
+		zap.Int64("size", size),
+	)
+	return nil
+}
+
+// newestArchived returns the newest file archived below the source's key
+// prefix, or "" if there is none.
+func (a *archiver) newestArchived(ctx context.Context) (string, error) {
+	listCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	objects, err := a.backupWriter.ListObjects(listCtx, a.src.keyPrefix())
+	if err != nil {
+		return "", fmt.Errorf("failed to list archived %s files: %w", a.src.name(), err)
+	}
+	newest := ""
+	for _, obj := range objects {
+		if name := writer.LogFileName(obj.Key); name > newest {
+			newest = name
+		}
+	}
+	return newest, nil
+}
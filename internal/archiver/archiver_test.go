@@ -0,0 +1,67 @@
+package archiver
+
+import (
+	"reflect"
+	"testing"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/model"
+)
+
+func TestChooseStartFile(t *testing.T) {
+	serverLogs := []string{"mysql-bin.000004", "mysql-bin.000005", "mysql-bin.000006"}
+	tests := []struct {
+		name      string
+		resume    string
+		wantStart string
+		wantGap   bool
+	}{
+		{name: "first run", resume: "", wantStart: "mysql-bin.000004"},
+		{name: "resume", resume: "mysql-bin.000005", wantStart: "mysql-bin.000005"},
+		{name: "purged", resume: "mysql-bin.000002", wantStart: "mysql-bin.000004", wantGap: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, gap := chooseStartFile(serverLogs, tt.resume)
+			if start != tt.wantStart || gap != tt.wantGap {
+				t.Errorf("chooseStartFile() = %s, %v, want %s, %v", start, gap, tt.wantStart, tt.wantGap)
+			}
+		})
+	}
+}
+
+func TestWALStates(t *testing.T) {
+	names := []string{
+		"000000010000000000000002",
+		"000000010000000000000003",
+		"000000010000000000000004.partial",
+		"00000002.history",
+	}
+	got := (&walSource{}).states(names)
+	want := []fileState{fileComplete, fileKept, fileSkipped, fileComplete}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("states() = %v, want %v", got, want)
+	}
+}
+
+func TestSameSlot(t *testing.T) {
+	physical := model.BackupSpec{Type: dumper.PostgresPhysicalDumperType, Conn: "postgres://db:5432/"}
+	tests := []struct {
+		name string
+		next model.BackupSpec
+		want bool
+	}{
+		{"unchanged", physical, true},
+		{"default slot named", model.BackupSpec{Type: physical.Type, Conn: physical.Conn, PgSlot: DefaultReplicationSlot}, true},
+		{"other slot", model.BackupSpec{Type: physical.Type, Conn: physical.Conn, PgSlot: "other"}, false},
+		{"other server", model.BackupSpec{Type: physical.Type, Conn: "postgres://db2:5432/"}, false},
+		{"no longer physical", model.BackupSpec{Type: "postgres", Conn: physical.Conn}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameSlot(physical, tt.next); got != tt.want {
+				t.Errorf("sameSlot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
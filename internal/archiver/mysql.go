@@ -0,0 +1,146 @@
+package archiver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+
+	"go.uber.org/zap"
+)
+
+// binlogSource streams the binary logs of a MySQL server with mysqlbinlog.
+type binlogSource struct {
+	spec model.BackupSpec
+}
+
+func (s *binlogSource) name() string { return "binlog" }
+
+func (s *binlogSource) keyPrefix() string { return writer.BinlogKeyPrefix(s.spec) }
+
+func (s *binlogSource) command(ctx context.Context, a *archiver) (*exec.Cmd, error) {
+	params, err := dumper.ParseMySQLURI(s.spec.Conn)
+	if err != nil {
+		return nil, err
+	}
+	serverLogs, err := listServerBinlogs(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	resume, err := s.resumeFile(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	start, gap := chooseStartFile(serverLogs, resume)
+	if start == "" {
+		return nil, fmt.Errorf("the server lists no binary logs, binary logging must be enabled")
+	}
+	if gap {
+		logger.Log.Error("Binary log was purged from the server before it was archived, point-in-time recovery has a gap until the next full backup",
+			zap.String("containerID", a.containerID),
+			zap.String("missing", resume),
+			zap.String("resumingAt", start),
+		)
+	}
+
+	args := append(mysqlClientArgs(params),
+		"--read-from-remote-server", "--raw", "--stop-never",
+		"--result-file="+a.dir+string(os.PathSeparator),
+		start,
+	)
+	cmd := exec.CommandContext(ctx, dumper.MySQLBinlogTool(), args...)
+	if params.Password != "" {
+		cmd.Env = append(os.Environ(), "MYSQL_PWD="+params.Password)
+	}
+	return cmd, nil
+}
+
+// states: mysqlbinlog only starts a file once the server rotated to it, so all
+// but the newest file are complete. The newest is uploaded again whenever it
+// grew, which bounds how much a lost server loses.
+func (s *binlogSource) states(names []string) []fileState {
+	states := make([]fileState, len(names))
+	for i := range names {
+		states[i] = fileComplete
+		if i == len(names)-1 {
+			states[i] = fileActive
+		}
+	}
+	return states
+}
+
+// resumeFile returns the binary log streaming continues with: the newest file
+// still staged, else the newest archived one, which is fetched again in full.
+func (s *binlogSource) resumeFile(ctx context.Context, a *archiver) (string, error) {
+	if entries, err := os.ReadDir(a.dir); err == nil {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Type().IsRegular() {
+				return entries[i].Name(), nil
+			}
+		}
+	}
+	return a.newestArchived(ctx)
+}
+
+// chooseStartFile returns the binary log to start streaming at: resume when the
+// server still has it, else the oldest one the server has. gap reports that
+// resume was purged before it was archived.
+func chooseStartFile(serverLogs []string, resume string) (start string, gap bool) {
+	if len(serverLogs) == 0 {
+		return "", false
+	}
+	if resume == "" {
+		return serverLogs[0], false
+	}
+	if slices.Contains(serverLogs, resume) {
+		return resume, false
+	}
+	return serverLogs[0], true
+}
+
+func mysqlClientArgs(params *dumper.MySQLConnParams) []string {
+	var args []string
+	if params.Host != "" {
+		args = append(args, "--host="+params.Host)
+	}
+	if params.Port != "" {
+		args = append(args, "--port="+params.Port)
+	}
+	if params.User != "" {
+		args = append(args, "--user="+params.User)
+	}
+	if strings.EqualFold(params.SSLMode, "disable") || strings.EqualFold(params.SSLMode, "disabled") {
+		args = append(args, "--ssl=0")
+	}
+	return args
+}
+
+// listServerBinlogs returns the binary logs the server still has, oldest first.
+func listServerBinlogs(ctx context.Context, params *dumper.MySQLConnParams) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "mysql", append(mysqlClientArgs(params), "-N", "-B", "-e", "SHOW BINARY LOGS")...)
+	if params.Password != "" {
+		cmd.Env = append(os.Environ(), "MYSQL_PWD="+params.Password)
+	}
+	var stdoutBuf, stderrBuf bytes.Buffer
+	cmd.Stdout = &stdoutBuf
+	cmd.Stderr = &stderrBuf
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to list binary logs: %w (stderr: %s)", err, strings.TrimSpace(stderrBuf.String()))
+	}
+
+	var logs []string
+	for _, line := range strings.Split(stdoutBuf.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			logs = append(logs, fields[0])
+		}
+	}
+	return logs, nil
+}
@@ -0,0 +1,117 @@
+package archiver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+)
+
+// DefaultReplicationSlot is the slot pg_receivewal streams from unless
+// backup.pg.slot names another one.
+const DefaultReplicationSlot = "label_backup"
+
+// partialSuffix marks the WAL segment pg_receivewal is still writing.
+const partialSuffix = ".partial"
+
+// slotName returns the replication slot the WAL of spec is streamed from.
+func slotName(spec model.BackupSpec) string {
+	if spec.PgSlot != "" {
+		return spec.PgSlot
+	}
+	return DefaultReplicationSlot
+}
+
+// sameSlot reports whether b streams through the replication slot a used.
+func sameSlot(a, b model.BackupSpec) bool {
+	return b.Type == dumper.PostgresPhysicalDumperType && a.Conn == b.Conn && slotName(a) == slotName(b)
+}
+
+// walSource streams the WAL of a PostgreSQL cluster with pg_receivewal.
+type walSource struct {
+	spec model.BackupSpec
+}
+
+func (s *walSource) name() string { return "wal" }
+
+func (s *walSource) keyPrefix() string { return writer.WALKeyPrefix(s.spec) }
+
+// command streams through a replication slot, which makes the server keep WAL
+// that was not received yet, e.g. while label-backup restarts. pg_receivewal
+// continues after the newest segment still staged.
+func (s *walSource) command(ctx context.Context, a *archiver) (*exec.Cmd, error) {
+	params, err := dumper.ParsePostgresURI(s.spec.Conn)
+	if err != nil {
+		return nil, err
+	}
+	env := replicationEnv(params)
+	slot := slotName(s.spec)
+
+	create := exec.CommandContext(ctx, "pg_receivewal", append(dumper.PostgresReplicationArgs(params),
+		"--slot="+slot, "--create-slot", "--if-not-exists")...)
+	create.Env = env
+	var stderrBuf bytes.Buffer
+	create.Stderr = &stderrBuf
+	if err := create.Run(); err != nil {
+		return nil, fmt.Errorf("failed to create replication slot %s: %w (stderr: %s)", slot, err, strings.TrimSpace(stderrBuf.String()))
+	}
+
+	cmd := exec.CommandContext(ctx, "pg_receivewal", append(dumper.PostgresReplicationArgs(params),
+		"-D", a.dir, "--slot="+slot, "--no-loop")...)
+	cmd.Env = env
+	return cmd, nil
+}
+
+// dropSlot drops the replication slot once the stream stopped for good, so
+// the server no longer keeps WAL for it.
+func (s *walSource) dropSlot(ctx context.Context) error {
+	params, err := dumper.ParsePostgresURI(s.spec.Conn)
+	if err != nil {
+		return err
+	}
+	drop := exec.CommandContext(ctx, "pg_receivewal", append(dumper.PostgresReplicationArgs(params),
+		"--slot="+slotName(s.spec), "--drop-slot")...)
+	drop.Env = replicationEnv(params)
+	var stderrBuf bytes.Buffer
+	drop.Stderr = &stderrBuf
+	if err := drop.Run(); err != nil {
+		return fmt.Errorf("%w (stderr: %s)", err, strings.TrimSpace(stderrBuf.String()))
+	}
+	return nil
+}
+
+func replicationEnv(params *dumper.PostgresConnParams) []string {
+	if params.Password == "" {
+		return nil
+	}
+	return append(os.Environ(), "PGPASSWORD="+params.Password)
+}
+
+// states: the segment being written is only uploaded once it is complete and
+// pg_receivewal renamed it. The newest complete segment stays staged, as that
+// is where pg_receivewal resumes.
+func (s *walSource) states(names []string) []fileState {
+	states := make([]fileState, len(names))
+	newest := -1
+	for i, name := range names {
+		switch {
+		case strings.HasSuffix(name, partialSuffix):
+			states[i] = fileSkipped
+		case writer.IsWALSegment(name):
+			states[i] = fileComplete
+			newest = i
+		default:
+			states[i] = fileComplete
+		}
+	}
+	if newest != -1 {
+		states[newest] = fileKept
+	}
+	return states
+}
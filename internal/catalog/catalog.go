@@ -0,0 +1,229 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Filter selects catalog entries. Zero values match everything.
+type Filter struct {
+	Container string
+	Type      string
+	Dest      string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+	Offset    int
+}
+
+type Entry struct {
+	Object        string    `json:"object"`
+	ContainerID   string    `json:"container_id"`
+	ContainerName string    `json:"container_name"`
+	DatabaseType  string    `json:"database_type"`
+	DatabaseName  string    `json:"database_name,omitempty"`
+	Dest          string    `json:"dest"`
+	Timestamp     time.Time `json:"timestamp"`
+	SizeBytes     int64     `json:"size_bytes"`
+	Checksum      string    `json:"checksum,omitempty"`
+	Success       *bool     `json:"success,omitempty"`
+	Encrypted     bool      `json:"encrypted,omitempty"`
+	Destination   string    `json:"destination,omitempty"`
+	VerifyStatus  string    `json:"verify_status,omitempty"`
+	HasMetadata   bool      `json:"has_metadata"`
+}
+
+type Page struct {
+	Backups    []Entry `json:"backups"`
+	Total      int     `json:"total"`
+	Limit      int     `json:"limit"`
+	Offset     int     `json:"offset"`
+	NextOffset *int    `json:"next_offset,omitempty"`
+}
+
+type candidate struct {
+	entry      Entry
+	hasSidecar bool
+	writer     writer.BackupWriter
+}
+
+// List walks every spec's prefix on its destination, filters and sorts the backups
+// newest first, and joins only the requested page to its metadata sidecars.
+func List(ctx context.Context, specs map[string]model.BackupSpec, globalConfig map[string]string, filter Filter) (*Page, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	offset := filter.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	// Iterate in a stable order so pagination is deterministic
+	containerIDs := make([]string, 0, len(specs))
+	for id := range specs {
+		containerIDs = append(containerIDs, id)
+	}
+	sort.Strings(containerIDs)
+
+	seen := make(map[string]bool)
+	var candidates []candidate
+	for _, containerID := range containerIDs {
+		spec := specs[containerID]
+		if !matchesSpec(containerID, spec, filter) {
+			continue
+		}
+
+		backupWriter, err := writer.GetWriter(spec, globalConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get writer for container %s: %w", spec.ContainerName, err)
+		}
+		objects, err := backupWriter.ListObjects(ctx, spec.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list backups for container %s: %w", spec.ContainerName, err)
+		}
+
+		sidecars := make(map[string]bool)
+		for _, obj := range objects {
+			if strings.HasSuffix(obj.Key, writer.MetadataSuffix) {
+				sidecars[obj.Key] = true
+			}
+		}
+
+		for _, obj := range objects {
+			if !writer.IsObjectOf(spec, obj.Key) || strings.HasSuffix(obj.Key, writer.MetadataSuffix) {
+				continue
+			}
+			// Containers sharing a prefix and database name list the same keys
+			dedupeKey := spec.Dest + "|" + obj.Key
+			if seen[dedupeKey] {
+				continue
+			}
+			seen[dedupeKey] = true
+
+			timestamp, ok := writer.ParseObjectTimestamp(obj.Key)
+			if !ok {
+				timestamp = obj.LastModified
+			}
+			if !filter.Since.IsZero() && timestamp.Before(filter.Since) {
+				continue
+			}
+			if !filter.Until.IsZero() && timestamp.After(filter.Until) {
+				continue
+			}
+
+			candidates = append(candidates, candidate{
+				entry: Entry{
+					Object:        obj.Key,
+					ContainerID:   containerID,
+					ContainerName: spec.ContainerName,
+					DatabaseType:  spec.Type,
+					DatabaseName:  spec.Database,
+					Dest:          spec.Dest,
+					Timestamp:     timestamp.UTC(),
+					SizeBytes:     obj.Size,
+					Checksum:      obj.Checksum,
+				},
+				hasSidecar: sidecars[obj.Key+writer.MetadataSuffix],
+				writer:     backupWriter,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].entry.Timestamp.Equal(candidates[j].entry.Timestamp) {
+			return candidates[i].entry.Object < candidates[j].entry.Object
+		}
+		return candidates[i].entry.Timestamp.After(candidates[j].entry.Timestamp)
+	})
+
+	page := &Page{Backups: []Entry{}, Total: len(candidates), Limit: limit, Offset: offset}
+	if offset >= len(candidates) {
+		return page, nil
+	}
+	end := offset + limit
+	if end > len(candidates) {
+		end = len(candidates)
+	}
+	if end < len(candidates) {
+		next := end
+		page.NextOffset = &next
+	}
+
+	for _, c := range candidates[offset:end] {
+		entry := c.entry
+		if c.hasSidecar {
+			metadata, err := writer.ReadMetadata(ctx, c.writer, entry.Object)
+			if err != nil {
+				logger.Log.Warn("Catalog: failed to read backup metadata",
+					zap.String("object", entry.Object),
+					zap.Error(err),
+				)
+			} else {
+				applyMetadata(&entry, metadata)
+			}
+		}
+		page.Backups = append(page.Backups, entry)
+	}
+	return page, nil
+}
+
+func applyMetadata(entry *Entry, metadata *writer.BackupMetadata) {
+	entry.HasMetadata = true
+	success := metadata.Success
+	entry.Success = &success
+	entry.Encrypted = metadata.Encrypted
+	entry.Destination = metadata.Destination
+	entry.VerifyStatus = metadata.VerifyStatus
+	if metadata.Checksum != "" {
+		entry.Checksum = metadata.Checksum
+	}
+	if metadata.BackupSize > 0 {
+		entry.SizeBytes = metadata.BackupSize
+	}
+	if !metadata.Timestamp.IsZero() {
+		entry.Timestamp = metadata.Timestamp.UTC()
+	}
+}
+
+func matchesSpec(containerID string, spec model.BackupSpec, filter Filter) bool {
+	if filter.Container != "" && filter.Container != spec.ContainerName &&
+		filter.Container != containerID && !strings.HasPrefix(containerID, filter.Container) {
+		return false
+	}
+	if filter.Type != "" && !strings.EqualFold(filter.Type, spec.Type) {
+		return false
+	}
+	if filter.Dest != "" && !strings.EqualFold(filter.Dest, spec.Dest) {
+		return false
+	}
+	return true
+}
+
+// ResolveSpec finds the spec that produced objectName, so the object is read through
+// the right writer.
+func ResolveSpec(specs map[string]model.BackupSpec, objectName string) (model.BackupSpec, bool) {
+	for _, spec := range specs {
+		if writer.IsObjectOf(spec, objectName) {
+			return spec, true
+		}
+	}
+	return model.BackupSpec{}, false
+}
@@ -0,0 +1,128 @@
+package catalog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+)
+
+func writeTestBackup(t *testing.T, cfg map[string]string, objectName string, withMetadata bool) {
+	t.Helper()
+	ctx := context.Background()
+	backupWriter, err := writer.GetWriter(model.BackupSpec{Dest: "local"}, cfg)
+	if err != nil {
+		t.Fatalf("GetWriter() error = %v", err)
+	}
+	if _, _, _, err := backupWriter.Write(ctx, objectName, strings.NewReader("dump")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !withMetadata {
+		return
+	}
+	metadata := writer.BackupMetadata{
+		DatabaseType: "postgres",
+		Checksum:     "abc123",
+		Destination:  "local",
+		Success:      true,
+	}
+	if err := writer.WriteMetadata(ctx, backupWriter, metadata, objectName); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	cfg := map[string]string{writer.GlobalConfigKeyLocalPath: t.TempDir()}
+	specs := map[string]model.BackupSpec{
+		"aaa111": {ContainerID: "aaa111", ContainerName: "app-db", Type: "postgres", Database: "app", Dest: "local", Prefix: "db"},
+		"bbb222": {ContainerID: "bbb222", ContainerName: "shop-db", Type: "mysql", Database: "shop", Dest: "local", Prefix: "db"},
+	}
+
+	writeTestBackup(t, cfg, "db/postgres-app-20250101020000.dump.gz", true)
+	writeTestBackup(t, cfg, "db/postgres-app-20250102020000.dump.gz", false)
+	writeTestBackup(t, cfg, "db/postgres-app-20250103020000.dump.gz", true)
+	writeTestBackup(t, cfg, "db/mysql-shop-20250102120000.dump.gz", true)
+
+	ctx := context.Background()
+
+	t.Run("all containers newest first", func(t *testing.T) {
+		page, err := List(ctx, specs, cfg, Filter{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		var got []string
+		for _, entry := range page.Backups {
+			got = append(got, entry.Object)
+		}
+		want := []string{
+			"db/postgres-app-20250103020000.dump.gz",
+			"db/mysql-shop-20250102120000.dump.gz",
+			"db/postgres-app-20250102020000.dump.gz",
+			"db/postgres-app-20250101020000.dump.gz",
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("objects = %v, want %v", got, want)
+		}
+		if page.Backups[0].Checksum != "abc123" || page.Backups[0].Success == nil || !*page.Backups[0].Success {
+			t.Errorf("first entry not joined to metadata: %+v", page.Backups[0])
+		}
+		if page.Backups[2].HasMetadata || page.Backups[2].Success != nil {
+			t.Errorf("entry without sidecar reported metadata: %+v", page.Backups[2])
+		}
+	})
+
+	t.Run("filters and pagination", func(t *testing.T) {
+		filter := Filter{
+			Container: "app-db",
+			Since:     time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+			Limit:     1,
+		}
+		page, err := List(ctx, specs, cfg, filter)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if page.Total != 2 || len(page.Backups) != 1 {
+			t.Fatalf("total = %d, page size = %d, want 2 and 1", page.Total, len(page.Backups))
+		}
+		if page.Backups[0].Object != "db/postgres-app-20250103020000.dump.gz" {
+			t.Errorf("object = %s", page.Backups[0].Object)
+		}
+		if page.NextOffset == nil || *page.NextOffset != 1 {
+			t.Errorf("next offset = %v, want 1", page.NextOffset)
+		}
+
+		filter.Offset = 1
+		page, err = List(ctx, specs, cfg, filter)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(page.Backups) != 1 || page.Backups[0].Object != "db/postgres-app-20250102020000.dump.gz" || page.NextOffset != nil {
+			t.Errorf("second page = %+v", page)
+		}
+	})
+}
+
+func TestResolveSpec(t *testing.T) {
+	specs := map[string]model.BackupSpec{
+		"aaa111": {ContainerID: "aaa111", Type: "postgres", Database: "app", Dest: "local", Prefix: "db"},
+		"bbb222": {ContainerID: "bbb222", Type: "postgres", Database: "app", Dest: "s3", Prefix: "db/prod"},
+	}
+
+	spec, ok := ResolveSpec(specs, "db/prod/postgres-app-20250101020000.dump.gz")
+	if !ok || spec.ContainerID != "bbb222" {
+		t.Errorf("ResolveSpec() = %s, %v, want bbb222", spec.ContainerID, ok)
+	}
+	spec, ok = ResolveSpec(specs, "db/postgres-app-20250101020000.dump.gz")
+	if !ok || spec.ContainerID != "aaa111" {
+		t.Errorf("ResolveSpec() = %s, %v, want aaa111", spec.ContainerID, ok)
+	}
+	if _, ok := ResolveSpec(specs, "db/postgres-app-staging-20250101020000.dump.gz"); ok {
+		t.Error("ResolveSpec() matched the backup of a database named like a spec's")
+	}
+	if _, ok := ResolveSpec(specs, "other/redis-cache-20250101020000.dump.gz"); ok {
+		t.Error("ResolveSpec() matched an object no spec owns")
+	}
+}
@@ -3,12 +3,17 @@ package discovery
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"label-backup/internal/dumper"
+	"label-backup/internal/encryption"
 	"label-backup/internal/logger"
+	"label-backup/internal/metrics"
 	"label-backup/internal/model"
 
 	"github.com/docker/docker/api/types"
@@ -21,9 +26,14 @@ import (
 
 type Registry map[string]model.BackupSpec
 
+// replicationSlotPattern matches the names PostgreSQL accepts for replication slots
+var replicationSlotPattern = regexp.MustCompile(`^[a-z0-9_]{1,63}$`)
+
 type Watcher struct {
 	cli               *client.Client
 	registry          Registry
+	// removed holds the containers destroyed or unlabeled since the last TakeRemoved
+	removed           map[string]struct{}
 	mu                sync.RWMutex
 	reconnectBackoff  time.Duration
 	maxBackoff        time.Duration
@@ -38,6 +48,7 @@ func NewWatcher() (*Watcher, error) {
 	w := &Watcher{
 		cli:              cli,
 		registry:         make(Registry),
+		removed:          make(map[string]struct{}),
 		reconnectBackoff: 1 * time.Second,
 		maxBackoff:       30 * time.Second,
 	}
@@ -166,6 +177,10 @@ func (w *Watcher) handleEvent(ctx context.Context, event events.Message) {
 
 	case "destroy", "die", "kill", "stop": 
 		w.mu.Lock()
+		// A stopped container keeps its jobs, as it usually comes back
+		if event.Action == "destroy" {
+			w.removed[event.Actor.ID] = struct{}{}
+		}
 		if _, ok := w.registry[event.Actor.ID]; ok {
 			delete(w.registry, event.Actor.ID)
 			logger.Log.Info("Unregistered backup spec due to container event",
@@ -178,13 +193,20 @@ func (w *Watcher) handleEvent(ctx context.Context, event events.Message) {
 				zap.String("eventAction", string(event.Action)),
 			)
 		}
+		w.updateRegistryMetric()
 		w.mu.Unlock()
 	}
 }
 
+// updateRegistryMetric publishes the registry size; the caller must hold w.mu.
+func (w *Watcher) updateRegistryMetric() {
+	metrics.DiscoveredContainers.Set(float64(len(w.registry)))
+}
+
 func (w *Watcher) parseAndRegister(container types.ContainerJSON) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	defer w.updateRegistryMetric()
 
 	logger.Log.Debug("Parsing labels for container", zap.String("containerName", container.Name), zap.String("containerID", container.ID))
 
@@ -197,6 +219,7 @@ func (w *Watcher) parseAndRegister(container types.ContainerJSON) {
 						zap.String("containerID", container.ID),
 					)
 					delete(w.registry, container.ID)
+					w.removed[container.ID] = struct{}{}
 				}
 			} else if enabledStr != "true" {
 				if _, exists := w.registry[container.ID]; exists {
@@ -205,18 +228,30 @@ func (w *Watcher) parseAndRegister(container types.ContainerJSON) {
 						zap.String("value", enabledStr),
 					)
 					delete(w.registry, container.ID)
+					w.removed[container.ID] = struct{}{}
 				}
 			}
 		} else {
 			if _, exists := w.registry[container.ID]; exists {
 				logger.Log.Info("Backup labels removed or backup.enabled not 'true'. Unregistering.", zap.String("containerID", container.ID))
 				delete(w.registry, container.ID)
+				w.removed[container.ID] = struct{}{}
 			}
 		}
 		return
 	}
 
+	// Restore drills default to the source container's image so versions match
+	if spec.VerifyCron != "" && spec.VerifyImage == "" && container.Config != nil {
+		spec.VerifyImage = container.Config.Image
+	}
+	// So do dump sidecars
+	if spec.Mode == "sidecar" && spec.SidecarImage == "" && container.Config != nil {
+		spec.SidecarImage = container.Config.Image
+	}
+
 	w.registry[container.ID] = spec
+	delete(w.removed, container.ID)
 	logger.Log.Info("Registered/Updated backup spec for container",
 		zap.String("containerID", container.ID),
 		zap.String("dbType", spec.Type),
@@ -226,6 +261,74 @@ func (w *Watcher) parseAndRegister(container types.ContainerJSON) {
 	)
 }
 
+// parseKeepCount parses a backup.retention.keep-* label. Malformed values are
+// returned as -1 so validateLabelValues rejects the spec instead of silently
+// falling back to age-based deletion.
+func parseKeepCount(value string, label string, containerID string) int {
+	if value == "" {
+		return 0
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		logger.Log.Warn("Invalid retention keep count",
+			zap.String("containerID", containerID),
+			zap.String("label", label),
+			zap.String("value", value),
+		)
+		return -1
+	}
+	return count
+}
+
+// parseList splits a comma-separated label into its trimmed entries. Empty
+// entries are kept so the dumper can reject them.
+func parseList(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	entries := strings.Split(value, ",")
+	for i := range entries {
+		entries[i] = strings.TrimSpace(entries[i])
+	}
+	return entries
+}
+
+// parseRetryMax parses backup.retry.max. nil means the label is unset and the global
+// default applies; malformed values come back as -1 for validateLabelValues.
+func parseRetryMax(value string, containerID string) *int {
+	if value == "" {
+		return nil
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		logger.Log.Warn("Invalid backup.retry.max value",
+			zap.String("containerID", containerID),
+			zap.String("value", value),
+		)
+		count = -1
+	}
+	return &count
+}
+
+// parseDurationLabel parses a Go duration label such as backup.retry.backoff. 0
+// means the global default applies; malformed values come back as -1 for
+// validateLabelValues.
+func parseDurationLabel(value string, label string, containerID string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logger.Log.Warn("Invalid duration label value",
+			zap.String("containerID", containerID),
+			zap.String("label", label),
+			zap.String("value", value),
+		)
+		return -1
+	}
+	return d
+}
+
 func parseRetentionDuration(retentionStr string, containerID string) time.Duration {
 	value := strings.TrimSpace(retentionStr)
 	if value == "" {
@@ -291,15 +394,124 @@ func validateLabelValues(spec *model.BackupSpec, containerID string) error {
 		return fmt.Errorf("invalid backup.dest value '%s': must be 'local' or 'remote'", spec.Dest)
 	}
 
+	// Validate mode
+	if spec.Mode != "" && spec.Mode != "client" && spec.Mode != "exec" && spec.Mode != "sidecar" {
+		return fmt.Errorf("invalid backup.mode value '%s': must be 'client', 'exec' or 'sidecar'", spec.Mode)
+	}
+
 	// Validate type
-	validTypes := map[string]bool{
-		"postgres": true,
-		"mysql":    true,
-		"mongodb":  true,
-		"redis":    true,
+	validTypes := dumper.RegisteredTypes()
+	if !slices.Contains(validTypes, spec.Type) {
+		return fmt.Errorf("invalid backup.type value '%s': must be one of %s", spec.Type, strings.Join(validTypes, ", "))
 	}
-	if !validTypes[spec.Type] {
-		return fmt.Errorf("invalid backup.type value '%s': must be one of postgres, mysql, mongodb, redis", spec.Type)
+	if spec.Type == dumper.ExecDumperType && spec.Command == "" {
+		return fmt.Errorf("backup.type=exec requires a backup.command label")
+	}
+	// In client mode the command would run in the agent, which holds the Docker socket
+	if spec.Type == dumper.ExecDumperType && spec.Mode != "exec" && spec.Mode != "sidecar" {
+		return fmt.Errorf("backup.type=exec requires backup.mode=exec or backup.mode=sidecar, commands are not run inside the label-backup container")
+	}
+
+	// Validate pg_dump format
+	if spec.PgFormat != "" || spec.PgJobs > 0 {
+		if spec.Type != dumper.PostgresDumperType {
+			return fmt.Errorf("backup.pg.format and backup.pg.jobs only apply to backup.type=postgres")
+		}
+		if spec.PgFormat != "" && spec.PgFormat != dumper.PgFormatCustom && spec.PgFormat != dumper.PgFormatDirectory {
+			return fmt.Errorf("invalid backup.pg.format value '%s': must be 'custom' or 'directory'", spec.PgFormat)
+		}
+		if spec.PgJobs > 1 && spec.PgFormat != dumper.PgFormatDirectory {
+			return fmt.Errorf("backup.pg.jobs needs backup.pg.format=directory, pg_dump only runs parallel jobs in directory format")
+		}
+	}
+
+	// Validate the replication slot of WAL archiving
+	if spec.PgSlot != "" {
+		if spec.Type != dumper.PostgresPhysicalDumperType {
+			return fmt.Errorf("backup.pg.slot only applies to backup.type=postgres-physical")
+		}
+		if !replicationSlotPattern.MatchString(spec.PgSlot) {
+			return fmt.Errorf("invalid backup.pg.slot value '%s': use up to 63 lowercase letters, digits and underscores", spec.PgSlot)
+		}
+	}
+
+	// Validate table filters; the patterns themselves are checked by the dumper
+	if dumper.HasTableFilters(*spec) {
+		switch spec.Type {
+		case dumper.PostgresDumperType, dumper.MySQLDumperType, dumper.MongoDBDumperType:
+		default:
+			return fmt.Errorf("table filters are not supported for backup.type=%s", spec.Type)
+		}
+	}
+
+	// Validate multi-database backups
+	if spec.MultiDatabase() {
+		if spec.Type != dumper.PostgresDumperType && spec.Type != dumper.MySQLDumperType {
+			return fmt.Errorf("backup.database='%s' selects several databases, which only backup.type=postgres and mysql support", spec.Database)
+		}
+		if spec.Database != model.AllDatabases && len(spec.DatabaseList()) == 0 {
+			return fmt.Errorf("invalid backup.database value '%s': list at least one database", spec.Database)
+		}
+	}
+	if spec.MySQLAllDatabases {
+		if spec.Type != dumper.MySQLDumperType {
+			return fmt.Errorf("backup.mysql.all-databases only applies to backup.type=mysql")
+		}
+		if spec.Database != "" {
+			return fmt.Errorf("backup.mysql.all-databases cannot be combined with backup.database")
+		}
+	}
+	if spec.MySQLBinlog {
+		if spec.Type != dumper.MySQLDumperType {
+			return fmt.Errorf("backup.mysql.binlog only applies to backup.type=mysql")
+		}
+		if spec.MultiDatabase() {
+			return fmt.Errorf("backup.mysql.binlog needs a single dump, use one database or backup.mysql.all-databases")
+		}
+	}
+
+	// Validate encryption settings
+	if !spec.Encrypt && len(spec.EncryptRecipients) > 0 {
+		return fmt.Errorf("backup.encrypt.recipients requires backup.encrypt=true or backup.encrypt=age")
+	}
+	if spec.EncryptionType == "gpg" && len(spec.EncryptRecipients) > 0 {
+		return fmt.Errorf("backup.encrypt.recipients is only supported with age encryption, not gpg")
+	}
+
+	// Validate GFS retention counts
+	keepCounts := map[string]int{
+		"keep-last":    spec.RetentionKeepLast,
+		"keep-daily":   spec.RetentionKeepDaily,
+		"keep-weekly":  spec.RetentionKeepWeekly,
+		"keep-monthly": spec.RetentionKeepMonthly,
+		"keep-yearly":  spec.RetentionKeepYearly,
+	}
+	for rule, count := range keepCounts {
+		if count < 0 {
+			return fmt.Errorf("invalid backup.retention.%s value: must be a non-negative integer", rule)
+		}
+	}
+
+	// Validate retry settings
+	if spec.RetryMax != nil && *spec.RetryMax < 0 {
+		return fmt.Errorf("invalid backup.retry.max value: must be a non-negative integer")
+	}
+	if spec.RetryBackoff < 0 {
+		return fmt.Errorf("invalid backup.retry.backoff value: must be a positive duration such as 30s or 5m")
+	}
+	if spec.RetryMaxBackoff < 0 {
+		return fmt.Errorf("invalid backup.retry.max-backoff value: must be a positive duration such as 30s or 5m")
+	}
+
+	// Validate hook timeouts
+	if spec.PreExecTimeout < 0 {
+		return fmt.Errorf("invalid backup.pre-exec.timeout value: must be a positive duration such as 30s or 5m")
+	}
+	if spec.PostExecTimeout < 0 {
+		return fmt.Errorf("invalid backup.post-exec.timeout value: must be a positive duration such as 30s or 5m")
+	}
+	if spec.PreExecHold && spec.PreExec == "" {
+		return fmt.Errorf("backup.pre-exec.hold requires a backup.pre-exec command")
 	}
 
 	// Basic cron validation (at least 5 fields)
@@ -308,6 +520,10 @@ func validateLabelValues(spec *model.BackupSpec, containerID string) error {
 		return fmt.Errorf("invalid backup.cron value '%s': must have at least 5 fields", spec.Cron)
 	}
 
+	if spec.VerifyCron != "" && len(strings.Fields(spec.VerifyCron)) < 5 {
+		return fmt.Errorf("invalid backup.verify.cron value '%s': must have at least 5 fields", spec.VerifyCron)
+	}
+
 	return nil
 }
 
@@ -344,7 +560,7 @@ func parseLabels(labels map[string]string, containerID, containerName string) (m
 	typeVal = strings.ToLower(typeVal)
 
 	conn := getLabel("backup.conn", "")
-	if conn == "" && typeVal != "redis" {
+	if conn == "" && typeVal != "redis" && typeVal != "exec" {
 		logger.Log.Warn("backup.conn label is missing or empty for enabled container", 
 		    zap.String("containerID", containerID), 
 		    zap.String("dbType", typeVal),
@@ -354,19 +570,99 @@ func parseLabels(labels map[string]string, containerID, containerName string) (m
 
 	retentionStr := getLabel("backup.retention", "")
 	retentionDuration := parseRetentionDuration(retentionStr, containerID)
+	keepCount := func(key string) int {
+		return parseKeepCount(getLabel(key, ""), key, containerID)
+	}
+
+	priority := 0
+	if priorityStr := getLabel("backup.priority", ""); priorityStr != "" {
+		var err error
+		priority, err = strconv.Atoi(priorityStr)
+		if err != nil {
+			logger.Log.Warn("Invalid backup.priority value, must be an integer",
+				zap.String("containerID", containerID),
+				zap.String("value", priorityStr),
+			)
+			return model.BackupSpec{}, false
+		}
+	}
+
+	pgJobs := 0
+	if jobsStr := getLabel("backup.pg.jobs", ""); jobsStr != "" {
+		var err error
+		pgJobs, err = strconv.Atoi(jobsStr)
+		if err != nil || pgJobs < 1 {
+			logger.Log.Warn("Invalid backup.pg.jobs value, must be a positive integer",
+				zap.String("containerID", containerID),
+				zap.String("value", jobsStr),
+			)
+			return model.BackupSpec{}, false
+		}
+	}
+
+	encrypt := false
+	encryptionType := ""
+	switch encryptStr := strings.ToLower(getLabel("backup.encrypt", "false")); encryptStr {
+	case "true":
+		encrypt = true
+	case "gpg", "age":
+		encrypt = true
+		encryptionType = encryptStr
+	case "false":
+	default:
+		logger.Log.Warn("Invalid backup.encrypt value, must be 'true', 'false', 'gpg' or 'age'",
+			zap.String("containerID", containerID),
+			zap.String("value", encryptStr),
+		)
+		return model.BackupSpec{}, false
+	}
+	encryptRecipients := encryption.ParseRecipientList(getLabel("backup.encrypt.recipients", ""))
 
 	spec := model.BackupSpec{
-		Enabled:       true,
-		Type:          typeVal,
-		Conn:          conn,
-		Database:      getLabel("backup.database", ""),
-		Cron:          cron,
-		Dest:          strings.ToLower(getLabel("backup.dest", "local")),
-		Prefix:        getLabel("backup.prefix", ""),
-		Webhook:       getLabel("backup.webhook", ""),
-		Retention:     retentionDuration,
-		ContainerID:   containerID,
-		ContainerName: strings.TrimPrefix(containerName, "/"),
+		Enabled:              true,
+		Type:                 typeVal,
+		Conn:                 conn,
+		Database:             getLabel("backup.database", ""),
+		Command:              getLabel("backup.command", ""),
+		TestCommand:          getLabel("backup.test-command", ""),
+		Cron:                 cron,
+		Dest:                 strings.ToLower(getLabel("backup.dest", "local")),
+		Mode:                 strings.ToLower(getLabel("backup.mode", "client")),
+		SidecarImage:         getLabel("backup.sidecar.image", ""),
+		PgFormat:             strings.ToLower(getLabel("backup.pg.format", "")),
+		PgJobs:               pgJobs,
+		PgSlot:               getLabel("backup.pg.slot", ""),
+		IncludeTables:        parseList(getLabel("backup.include-tables", "")),
+		ExcludeTables:        parseList(getLabel("backup.exclude-tables", "")),
+		ExcludeTableData:     parseList(getLabel("backup.exclude-table-data", "")),
+		Schemas:              parseList(getLabel("backup.schemas", "")),
+		MySQLAllDatabases:    strings.ToLower(getLabel("backup.mysql.all-databases", "false")) == "true",
+		MySQLBinlog:          strings.ToLower(getLabel("backup.mysql.binlog", "false")) == "true",
+		Prefix:               getLabel("backup.prefix", ""),
+		Webhook:              getLabel("backup.webhook", ""),
+		Retention:            retentionDuration,
+		RetentionKeepLast:    keepCount("backup.retention.keep-last"),
+		RetentionKeepDaily:   keepCount("backup.retention.keep-daily"),
+		RetentionKeepWeekly:  keepCount("backup.retention.keep-weekly"),
+		RetentionKeepMonthly: keepCount("backup.retention.keep-monthly"),
+		RetentionKeepYearly:  keepCount("backup.retention.keep-yearly"),
+		Encrypt:              encrypt,
+		EncryptionType:       encryptionType,
+		EncryptRecipients:    encryptRecipients,
+		VerifyCron:           getLabel("backup.verify.cron", ""),
+		VerifyQuery:          getLabel("backup.verify.query", ""),
+		VerifyImage:          getLabel("backup.verify.image", ""),
+		RetryMax:             parseRetryMax(getLabel("backup.retry.max", ""), containerID),
+		RetryBackoff:         parseDurationLabel(getLabel("backup.retry.backoff", ""), "backup.retry.backoff", containerID),
+		RetryMaxBackoff:      parseDurationLabel(getLabel("backup.retry.max-backoff", ""), "backup.retry.max-backoff", containerID),
+		Priority:             priority,
+		PreExec:              getLabel("backup.pre-exec", ""),
+		PreExecTimeout:       parseDurationLabel(getLabel("backup.pre-exec.timeout", ""), "backup.pre-exec.timeout", containerID),
+		PreExecHold:          strings.ToLower(getLabel("backup.pre-exec.hold", "false")) == "true",
+		PostExec:             getLabel("backup.post-exec", ""),
+		PostExecTimeout:      parseDurationLabel(getLabel("backup.post-exec.timeout", ""), "backup.post-exec.timeout", containerID),
+		ContainerID:          containerID,
+		ContainerName:        strings.TrimPrefix(containerName, "/"),
 	}
 
 	// Validate label values
@@ -393,6 +689,53 @@ func (w *Watcher) GetRegistry() Registry {
 	return registryCopy
 }
 
+// TakeRemoved returns the containers that were destroyed or lost their backup
+// labels since the last call, so their jobs can be removed.
+func (w *Watcher) TakeRemoved() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	removed := make([]string, 0, len(w.removed))
+	for containerID := range w.removed {
+		removed = append(removed, containerID)
+	}
+	clear(w.removed)
+	return removed
+}
+
+// FindSpec looks up a registered container by full ID, unique ID prefix or container name.
+func (w *Watcher) FindSpec(idOrName string) (string, model.BackupSpec, bool) {
+	if idOrName == "" {
+		return "", model.BackupSpec{}, false
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if spec, ok := w.registry[idOrName]; ok {
+		return idOrName, spec, true
+	}
+
+	name := strings.TrimPrefix(idOrName, "/")
+	var matchID string
+	var matchSpec model.BackupSpec
+	matches := 0
+	for id, spec := range w.registry {
+		if spec.ContainerName == name || strings.HasPrefix(id, idOrName) {
+			matchID, matchSpec = id, spec
+			matches++
+		}
+	}
+	if matches != 1 {
+		return "", model.BackupSpec{}, false
+	}
+	return matchID, matchSpec, true
+}
+
+// DockerClient exposes the watcher's Docker client for components that manage containers.
+func (w *Watcher) DockerClient() *client.Client {
+	return w.cli
+}
+
 func (w *Watcher) Close() error {
 	if w.cli != nil {
 		return w.cli.Close()
@@ -0,0 +1,224 @@
+package dockerexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"label-backup/internal/logger"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"go.uber.org/zap"
+)
+
+type Result struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+// Run executes cmd inside a running container and collects its output.
+// A non-zero exit code is reported in the Result, not as an error.
+func Run(ctx context.Context, cli *client.Client, containerID string, cmd []string, env []string) (*Result, error) {
+	var stdout bytes.Buffer
+	result, err := Stream(ctx, cli, containerID, cmd, env, &stdout)
+	if err != nil {
+		return nil, err
+	}
+	result.Stdout = stdout.String()
+	return result, nil
+}
+
+// Stream executes cmd inside a running container, copying its stdout to stdout
+// as it arrives and collecting stderr in the Result. A non-zero exit code is
+// reported in the Result, not as an error.
+func Stream(ctx context.Context, cli *client.Client, containerID string, cmd []string, env []string, stdout io.Writer) (*Result, error) {
+	execResp, err := cli.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          cmd,
+		Env:          env,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exec in container %s: %w", containerID, err)
+	}
+
+	attach, err := cli.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach to exec in container %s: %w", containerID, err)
+	}
+	defer attach.Close()
+
+	// The hijacked connection does not observe ctx, so close it on cancellation
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			attach.Close()
+		case <-done:
+		}
+	}()
+
+	var stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(stdout, &stderr, attach.Reader); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to read exec output from container %s: %w", containerID, err)
+	}
+
+	inspect, err := cli.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect exec in container %s: %w", containerID, err)
+	}
+
+	logger.Log.Debug("Exec in container finished",
+		zap.String("containerID", containerID),
+		zap.Strings("cmd", cmd),
+		zap.Int("exitCode", inspect.ExitCode),
+	)
+
+	return &Result{
+		ExitCode: inspect.ExitCode,
+		Stderr:   stderr.String(),
+	}, nil
+}
+
+// Session is a command started with Start. It keeps running with its stdin
+// attached until Close closes the stdin, so it can hold state such as a lock in
+// the container while something else runs.
+type Session struct {
+	cli         *client.Client
+	containerID string
+	execID      string
+	attach      types.HijackedResponse
+	stdout      readyWriter
+	stderr      bytes.Buffer
+	done        chan struct{}
+	copyErr     error
+}
+
+// readyWriter collects output and closes ready once the first line is complete.
+type readyWriter struct {
+	buf   bytes.Buffer
+	ready chan struct{}
+	once  sync.Once
+}
+
+func (w *readyWriter) Write(p []byte) (int, error) {
+	if bytes.IndexByte(p, '\n') != -1 {
+		w.once.Do(func() { close(w.ready) })
+	}
+	return w.buf.Write(p)
+}
+
+// Start starts cmd inside a running container with its stdin attached. ctx only
+// bounds starting the command; the session lasts until Close.
+func Start(ctx context.Context, cli *client.Client, containerID string, cmd []string, env []string) (*Session, error) {
+	execResp, err := cli.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          cmd,
+		Env:          env,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exec in container %s: %w", containerID, err)
+	}
+
+	attach, err := cli.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach to exec in container %s: %w", containerID, err)
+	}
+
+	s := &Session{
+		cli:         cli,
+		containerID: containerID,
+		execID:      execResp.ID,
+		attach:      attach,
+		stdout:      readyWriter{ready: make(chan struct{})},
+		done:        make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		_, s.copyErr = stdcopy.StdCopy(&s.stdout, &s.stderr, attach.Reader)
+	}()
+	return s, nil
+}
+
+// Ready is closed once the command has written its first line to stdout.
+func (s *Session) Ready() <-chan struct{} {
+	return s.stdout.ready
+}
+
+// Done is closed once the command has exited.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close closes the command's stdin and waits for it to exit, or for ctx to be
+// done, in which case the connection is dropped and the command is left to
+// exit on its own. A non-zero exit code is reported in the Result, not as an
+// error.
+func (s *Session) Close(ctx context.Context) (*Result, error) {
+	defer s.attach.Close()
+	if err := s.attach.CloseWrite(); err != nil {
+		logger.Log.Debug("Failed to close exec stdin", zap.String("containerID", s.containerID), zap.Error(err))
+	}
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		s.attach.Close()
+		<-s.done
+		return nil, ctx.Err()
+	}
+	if s.copyErr != nil {
+		return nil, fmt.Errorf("failed to read exec output from container %s: %w", s.containerID, s.copyErr)
+	}
+
+	inspect, err := s.cli.ContainerExecInspect(ctx, s.execID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect exec in container %s: %w", s.containerID, err)
+	}
+	return &Result{
+		ExitCode: inspect.ExitCode,
+		Stdout:   s.stdout.buf.String(),
+		Stderr:   s.stderr.String(),
+	}, nil
+}
+
+// PrimaryNetwork returns the first, by name, of the networks containerID is
+// attached to, skipping host and none. It returns "" if there is none, in which
+// case new containers use the default network.
+func PrimaryNetwork(ctx context.Context, cli *client.Client, containerID string) string {
+	inspect, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		logger.Log.Warn("Failed to inspect container, using the default network",
+			zap.String("containerID", containerID),
+			zap.Error(err),
+		)
+		return ""
+	}
+	if inspect.NetworkSettings == nil {
+		return ""
+	}
+
+	var names []string
+	for name := range inspect.NetworkSettings.Networks {
+		if name != "host" && name != "none" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
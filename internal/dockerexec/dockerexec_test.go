@@ -0,0 +1,23 @@
+package dockerexec
+
+import "testing"
+
+func TestReadyWriter(t *testing.T) {
+	w := readyWriter{ready: make(chan struct{})}
+	w.Write([]byte("locking"))
+	select {
+	case <-w.ready:
+		t.Fatal("readyWriter is ready before a line is complete")
+	default:
+	}
+
+	w.Write([]byte(" tables\nheld\n"))
+	select {
+	case <-w.ready:
+	default:
+		t.Fatal("readyWriter is not ready after the first line")
+	}
+	if got := w.buf.String(); got != "locking tables\nheld\n" {
+		t.Errorf("readyWriter collected %q", got)
+	}
+}
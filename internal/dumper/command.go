@@ -0,0 +1,73 @@
+package dumper
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// ExecDumperType backs up anything a shell command can write to stdout, for
+// services without a built-in dumper.
+const ExecDumperType = "exec"
+
+type CommandDumper struct {
+	spec model.BackupSpec
+}
+
+func init() {
+	RegisterDumperFactory(ExecDumperType, NewCommandDumper)
+}
+
+func NewCommandDumper(spec model.BackupSpec) (Dumper, error) {
+	if spec.Type != ExecDumperType {
+		err := fmt.Errorf("invalid dumper type for exec: %s", spec.Type)
+		logger.Log.Error("Failed to create new CommandDumper",
+			zap.String("expectedType", ExecDumperType),
+			zap.String("providedType", spec.Type),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	if spec.Command == "" {
+		return nil, fmt.Errorf("backup.type=exec requires a backup.command label")
+	}
+	return &CommandDumper{spec: spec}, nil
+}
+
+// shellCommand runs command with sh -c, passing the connection labels through
+// the environment so commands do not have to repeat them.
+func shellCommand(spec model.BackupSpec, command string) dumpCommand {
+	return dumpCommand{
+		name: "sh",
+		args: []string{"-c", command},
+		env:  []string{"LABEL_BACKUP_CONN=" + spec.Conn, "LABEL_BACKUP_DATABASE=" + spec.Database},
+	}
+}
+
+func (d *CommandDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io.Writer) error {
+	logger.Log.Info("Executing backup command",
+		zap.String("containerID", spec.ContainerID),
+		zap.String("mode", spec.Mode),
+		zap.String("command", spec.Command),
+	)
+	return runDump(ctx, spec, shellCommand(spec, spec.Command), writer)
+}
+
+// TestConnection runs backup.test-command if one is set; without it there is
+// nothing to test.
+func (d *CommandDumper) TestConnection(ctx context.Context, spec model.BackupSpec) error {
+	if spec.TestCommand == "" {
+		logger.Log.Debug("No backup.test-command set, skipping connection test", zap.String("containerID", spec.ContainerID))
+		return nil
+	}
+	if stderr, err := runCheck(ctx, spec, shellCommand(spec, spec.TestCommand)); err != nil {
+		return fmt.Errorf("backup.test-command failed: %w (stderr: %s)", err, stderr)
+	}
+	logger.Log.Debug("Backup test command successful", zap.String("containerID", spec.ContainerID))
+	return nil
+}
@@ -0,0 +1,50 @@
+package dumper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+
+	"label-backup/internal/model"
+)
+
+func TestCommandDumper(t *testing.T) {
+	spec := model.BackupSpec{
+		Type:        ExecDumperType,
+		Database:    "files",
+		Command:     `printf 'backup of %s' "$LABEL_BACKUP_DATABASE"`,
+		TestCommand: "exit 3",
+		ContainerID: "abc123",
+	}
+	d, err := GetDumper(spec)
+	if err != nil {
+		t.Fatalf("GetDumper() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := d.Dump(context.Background(), spec, &buf); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("Dump() output is not gzipped: %v", err)
+	}
+	out, _ := io.ReadAll(gz)
+	if string(out) != "backup of files" {
+		t.Errorf("Dump() output = %q, want %q", out, "backup of files")
+	}
+
+	if err := d.TestConnection(context.Background(), spec); err == nil {
+		t.Error("TestConnection() succeeded although backup.test-command failed")
+	}
+	spec.TestCommand = ""
+	if err := d.TestConnection(context.Background(), spec); err != nil {
+		t.Errorf("TestConnection() without backup.test-command error = %v", err)
+	}
+
+	if _, err := GetDumper(model.BackupSpec{Type: ExecDumperType}); err == nil {
+		t.Error("GetDumper() accepted an exec spec without backup.command")
+	}
+}
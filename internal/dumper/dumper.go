@@ -5,11 +5,15 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/url"
 	"os/exec"
+	"sort"
+	"strings"
 	"sync"
 
 	"label-backup/internal/logger"
 	"label-backup/internal/model"
+	"label-backup/internal/writer"
 
 	"go.uber.org/zap"
 )
@@ -20,6 +24,41 @@ type Dumper interface {
 	TestConnection(ctx context.Context, spec model.BackupSpec) error
 }
 
+// Part is one object of a backup that is split across several objects, such as a
+// single database of a cluster backup. Its Dumper dumps it with Spec.
+type Part struct {
+	// Name is appended to the run's object name and is unique within the run
+	Name     string
+	Kind     string
+	Database string
+	Dumper   Dumper
+	Spec     model.BackupSpec
+}
+
+const (
+	PartKindDatabase = "database"
+	PartKindGlobals  = "globals"
+)
+
+// MultiDumper is implemented by dumpers that split some specs into several
+// objects. Parts returns nil when spec is backed up as a single object.
+type MultiDumper interface {
+	Parts(ctx context.Context, spec model.BackupSpec) ([]Part, error)
+}
+
+// PositionDumper is implemented by dumpers that record where in the server's
+// binary log a dump is consistent, for point-in-time recovery. BinlogPosition
+// returns the position of the last successful Dump, or nil if none was recorded.
+type PositionDumper interface {
+	BinlogPosition() *writer.BinlogPosition
+}
+
+// WALDumper is PositionDumper for PostgreSQL base backups: WALPosition returns
+// where in the write-ahead log the last successful Dump starts, or nil.
+type WALDumper interface {
+	WALPosition() *writer.WALPosition
+}
+
 type NewDumperFunc func(spec model.BackupSpec) (Dumper, error)
 
 var dumperFactories = make(map[string]NewDumperFunc)
@@ -35,6 +74,16 @@ func RegisterDumperFactory(dbType string, factory NewDumperFunc) {
 	logger.Log.Info("Registered dumper factory", zap.String("dbType", dbType))
 }
 
+// RegisteredTypes returns the database types with a registered dumper, sorted.
+func RegisteredTypes() []string {
+	types := make([]string, 0, len(dumperFactories))
+	for dbType := range dumperFactories {
+		types = append(types, dbType)
+	}
+	sort.Strings(types)
+	return types
+}
+
 func GetDumper(spec model.BackupSpec) (Dumper, error) {
 	factory, ok := dumperFactories[spec.Type]
 	if !ok {
@@ -49,6 +98,38 @@ func GetDumper(spec model.BackupSpec) (Dumper, error) {
 	return factory(spec)
 }
 
+// ConnHost returns the lower-cased database host from spec.Conn, without port or
+// credentials, or "" if it cannot be determined. Multi-host MongoDB URIs return
+// their sorted host list joined by commas.
+func ConnHost(spec model.BackupSpec) string {
+	if spec.Conn == "" {
+		return ""
+	}
+	if spec.Type == "redis" && !strings.Contains(spec.Conn, "://") {
+		params, err := ParseRedisConn(spec.Conn)
+		if err != nil {
+			return ""
+		}
+		return strings.ToLower(params.Host)
+	}
+
+	u, err := url.Parse(spec.Conn)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	if !strings.Contains(u.Host, ",") {
+		return strings.ToLower(u.Hostname())
+	}
+	var hosts []string
+	for _, hostPort := range strings.Split(u.Host, ",") {
+		if h := (&url.URL{Host: hostPort}).Hostname(); h != "" {
+			hosts = append(hosts, strings.ToLower(h))
+		}
+	}
+	sort.Strings(hosts)
+	return strings.Join(hosts, ",")
+}
+
 func StreamAndGzip(ctx context.Context, cmd *exec.Cmd, destWriter io.Writer) error {
 	logFields := []zap.Field{
 		zap.String("commandPath", cmd.Path),
@@ -0,0 +1,184 @@
+package dumper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"label-backup/internal/dockerexec"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"github.com/docker/docker/client"
+	"go.uber.org/zap"
+)
+
+const (
+	// ModeClient runs the dump tools shipped in the label-backup image against backup.conn.
+	ModeClient = "client"
+	// ModeExec runs the dump tools inside the database container through docker exec.
+	ModeExec = "exec"
+
+	// ExecHost is the address dump tools connect to in exec mode, where they run
+	// next to the server.
+	ExecHost = "127.0.0.1"
+)
+
+var dockerClient *client.Client
+
+// SetDockerClient sets the Docker client used for backup.mode=exec. It is called
+// once at startup, before any backup runs.
+func SetDockerClient(cli *client.Client) {
+	dockerClient = cli
+}
+
+// dumpCommand is a dump or connection test command. env holds the variables it
+// needs on top of the environment it runs in, such as PGPASSWORD.
+type dumpCommand struct {
+	name string
+	args []string
+	env  []string
+}
+
+// runDump streams the gzipped stdout of c into w, running it inside the labeled
+// container in exec mode or in a dump sidecar in sidecar mode.
+func runDump(ctx context.Context, spec model.BackupSpec, c dumpCommand, w io.Writer) error {
+	switch spec.Mode {
+	case ModeExec:
+		return StreamExecAndGzip(ctx, spec.ContainerID, append([]string{c.name}, c.args...), c.env, w)
+	case ModeSidecar:
+		return StreamSidecarAndGzip(ctx, spec, c, w)
+	}
+	cmd := exec.CommandContext(ctx, c.name, c.args...)
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
+	return StreamAndGzip(ctx, cmd, w)
+}
+
+// runCheck runs c to completion where runDump would run it and returns its
+// stderr along with any failure.
+func runCheck(ctx context.Context, spec model.BackupSpec, c dumpCommand) (string, error) {
+	_, stderr, err := runOutput(ctx, spec, c)
+	return stderr, err
+}
+
+// runOutput is runCheck for commands whose stdout is needed, such as queries
+// listing databases.
+func runOutput(ctx context.Context, spec model.BackupSpec, c dumpCommand) (string, string, error) {
+	if spec.Mode == ModeSidecar {
+		var stdout bytes.Buffer
+		res, err := runSidecar(ctx, spec, c, &stdout)
+		if err != nil {
+			return "", "", err
+		}
+		if res.exitCode != 0 {
+			return stdout.String(), res.stderr, fmt.Errorf("%s exited with code %d in sidecar", c.name, res.exitCode)
+		}
+		return stdout.String(), res.stderr, nil
+	}
+	if spec.Mode == ModeExec {
+		if dockerClient == nil {
+			return "", "", fmt.Errorf("backup.mode=exec needs a Docker client")
+		}
+		res, err := dockerexec.Run(ctx, dockerClient, spec.ContainerID, append([]string{c.name}, c.args...), c.env)
+		if err != nil {
+			return "", "", err
+		}
+		if res.ExitCode != 0 {
+			return res.Stdout, res.Stderr, fmt.Errorf("%s exited with code %d in container %s", c.name, res.ExitCode, spec.ContainerID)
+		}
+		return res.Stdout, res.Stderr, nil
+	}
+
+	cmd := exec.CommandContext(ctx, c.name, c.args...)
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
+	var stdoutBuf, stderrBuf bytes.Buffer
+	cmd.Stdout = &stdoutBuf
+	cmd.Stderr = &stderrBuf
+	err := cmd.Run()
+	return stdoutBuf.String(), stderrBuf.String(), err
+}
+
+// StreamExecAndGzip runs cmd inside a container through docker exec and gzips
+// its stdout into destWriter, like StreamAndGzip does for local commands.
+func StreamExecAndGzip(ctx context.Context, containerID string, cmd []string, env []string, destWriter io.Writer) error {
+	if dockerClient == nil {
+		return fmt.Errorf("backup.mode=exec needs a Docker client")
+	}
+	logFields := []zap.Field{
+		zap.String("containerID", containerID),
+		zap.String("command", cmd[0]),
+	}
+
+	gw := gzip.NewWriter(destWriter)
+	defer gw.Close()
+
+	logger.Log.Info("StreamExecAndGzip: Started command in container", logFields...)
+	res, err := dockerexec.Stream(ctx, dockerClient, containerID, cmd, env, gw)
+	if err != nil {
+		logger.Log.Error("StreamExecAndGzip: exec failed", append(logFields, zap.Error(err))...)
+		return fmt.Errorf("failed to run '%s' in container %s: %w", cmd[0], containerID, err)
+	}
+	if res.ExitCode != 0 {
+		logger.Log.Error("StreamExecAndGzip: dump command failed",
+			append(logFields,
+				zap.Int("exitCode", res.ExitCode),
+				zap.String("stderr", res.Stderr),
+			)...)
+		return fmt.Errorf("dump command '%s' exited with code %d in container %s (stderr: %s)", cmd[0], res.ExitCode, containerID, res.Stderr)
+	}
+	if res.Stderr != "" {
+		logger.Log.Warn("StreamExecAndGzip: dump command completed with messages on stderr",
+			append(logFields, zap.String("stderr", res.Stderr))...)
+	}
+
+	logger.Log.Info("StreamExecAndGzip: successfully streamed and gzipped output", logFields...)
+	return nil
+}
+
+// firstAvailable runs the first of names found in the container's PATH, for
+// tools that are named differently across images. It is used whenever the tool
+// runs in a database image rather than the label-backup image.
+func firstAvailable(names []string, args []string) dumpCommand {
+	return firstAvailableWithOptions(names, nil, args)
+}
+
+// firstAvailableWithOptions is firstAvailable for tools that spell an option
+// differently: options[i] is passed before args when names[i] runs. The options
+// are fixed strings of this package and must not need shell quoting.
+func firstAvailableWithOptions(names []string, options [][]string, args []string) dumpCommand {
+	var script strings.Builder
+	for i, name := range names {
+		run := name
+		if i < len(options) && len(options[i]) > 0 {
+			run += " " + strings.Join(options[i], " ")
+		}
+		fmt.Fprintf(&script, "command -v %s >/dev/null 2>&1 && exec %s \"$@\"; ", name, run)
+	}
+	fmt.Fprintf(&script, "echo 'none of %s found' >&2; exit 127", strings.Join(names, ", "))
+	return dumpCommand{name: "sh", args: append([]string{"-c", script.String(), names[0]}, args...)}
+}
+
+// execMongoURI points a single-host MongoDB URI at ExecHost, keeping port,
+// credentials and options. mongodb+srv and multi-host URIs are returned as is.
+func execMongoURI(conn string) string {
+	u, err := url.Parse(conn)
+	if err != nil || u.Scheme != "mongodb" || u.Host == "" || strings.Contains(u.Host, ",") {
+		return conn
+	}
+	if port := u.Port(); port != "" {
+		u.Host = ExecHost + ":" + port
+	} else {
+		u.Host = ExecHost
+	}
+	return u.String()
+}
@@ -0,0 +1,197 @@
+package dumper
+
+import (
+	"fmt"
+	"strings"
+
+	"label-backup/internal/model"
+)
+
+// HasTableFilters reports whether spec limits what is dumped with the
+// backup.include-tables, backup.exclude-tables, backup.exclude-table-data or
+// backup.schemas labels.
+func HasTableFilters(spec model.BackupSpec) bool {
+	return len(spec.IncludeTables) > 0 || len(spec.ExcludeTables) > 0 || len(spec.ExcludeTableData) > 0 || len(spec.Schemas) > 0
+}
+
+// validatePatterns rejects empty patterns and patterns that cannot be passed as a
+// single command-line argument.
+func validatePatterns(label string, patterns []string) error {
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			return fmt.Errorf("%s contains an empty entry", label)
+		}
+		if strings.ContainsAny(p, "\x00\r\n") {
+			return fmt.Errorf("%s entry %q contains a control character", label, p)
+		}
+	}
+	return nil
+}
+
+func validateCommonFilters(spec model.BackupSpec) error {
+	for label, patterns := range map[string][]string{
+		"backup.include-tables":     spec.IncludeTables,
+		"backup.exclude-tables":     spec.ExcludeTables,
+		"backup.exclude-table-data": spec.ExcludeTableData,
+		"backup.schemas":            spec.Schemas,
+	} {
+		if err := validatePatterns(label, patterns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePostgresFilters checks the filters against pg_dump's pattern syntax:
+// psql patterns of at most database.schema.table with balanced double quotes.
+func validatePostgresFilters(spec model.BackupSpec) error {
+	if err := validateCommonFilters(spec); err != nil {
+		return err
+	}
+	for _, patterns := range [][]string{spec.IncludeTables, spec.ExcludeTables, spec.ExcludeTableData, spec.Schemas} {
+		for _, p := range patterns {
+			if strings.Count(p, `"`)%2 != 0 {
+				return fmt.Errorf("pattern %q has an unbalanced double quote", p)
+			}
+			if strings.Count(unquotedPattern(p), ".") > 2 {
+				return fmt.Errorf("pattern %q has too many dotted parts, at most database.schema.table is allowed", p)
+			}
+		}
+	}
+	for _, p := range spec.Schemas {
+		if strings.Count(unquotedPattern(p), ".") > 1 {
+			return fmt.Errorf("schema pattern %q has too many dotted parts, at most database.schema is allowed", p)
+		}
+	}
+	return nil
+}
+
+// unquotedPattern drops the double-quoted sections of a psql pattern, in which
+// dots are literal.
+func unquotedPattern(p string) string {
+	var b strings.Builder
+	quoted := false
+	for _, r := range p {
+		if r == '"' {
+			quoted = !quoted
+			continue
+		}
+		if !quoted {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// postgresFilterArgs translates the filters to pg_dump options. --strict-names
+// makes an include pattern that matches nothing fail before any data is dumped.
+func postgresFilterArgs(spec model.BackupSpec) []string {
+	var args []string
+	for _, p := range spec.Schemas {
+		args = append(args, "-n", p)
+	}
+	for _, p := range spec.IncludeTables {
+		args = append(args, "-t", p)
+	}
+	for _, p := range spec.ExcludeTables {
+		args = append(args, "-T", p)
+	}
+	for _, p := range spec.ExcludeTableData {
+		args = append(args, "--exclude-table-data="+p)
+	}
+	if len(spec.Schemas) > 0 || len(spec.IncludeTables) > 0 {
+		args = append(args, "--strict-names")
+	}
+	return args
+}
+
+// validateMySQLFilters checks that the filters are table names, which is all
+// mysqldump accepts, in the dumped database. An empty db means the backup spans
+// several databases, where only exclusions are possible.
+func validateMySQLFilters(spec model.BackupSpec, db string) error {
+	if err := validateCommonFilters(spec); err != nil {
+		return err
+	}
+	if len(spec.ExcludeTableData) > 0 {
+		return fmt.Errorf("backup.exclude-table-data is not supported for MySQL")
+	}
+	if len(spec.Schemas) > 0 {
+		return fmt.Errorf("backup.schemas is not supported for MySQL, use backup.database")
+	}
+	if db == "" && len(spec.IncludeTables) > 0 {
+		return fmt.Errorf("backup.include-tables needs a single database in backup.database")
+	}
+	for _, patterns := range [][]string{spec.IncludeTables, spec.ExcludeTables} {
+		for _, p := range patterns {
+			if strings.ContainsAny(p, "*?%") {
+				return fmt.Errorf("table %q: mysqldump does not support wildcards, list the tables", p)
+			}
+			schema, _, ok := strings.Cut(p, ".")
+			if ok && db != "" && schema != db {
+				return fmt.Errorf("table %q is not in the dumped database %s", p, db)
+			}
+			if !ok && spec.MySQLAllDatabases {
+				return fmt.Errorf("table %q: backup.mysql.all-databases needs tables as database.table", p)
+			}
+		}
+	}
+	return nil
+}
+
+// mysqlFilterArgs returns the tables to dump after the database name and the
+// --ignore-table options for db. Unqualified exclusions apply to db, qualified
+// ones only to their own database; with an empty db every exclusion is qualified.
+func mysqlFilterArgs(spec model.BackupSpec, db string) (tables []string, options []string) {
+	for _, p := range spec.IncludeTables {
+		tables = append(tables, strings.TrimPrefix(p, db+"."))
+	}
+	for _, p := range spec.ExcludeTables {
+		schema, table, ok := strings.Cut(p, ".")
+		if !ok {
+			schema, table = db, p
+		}
+		if db != "" && schema != db {
+			continue
+		}
+		options = append(options, "--ignore-table="+schema+"."+table)
+	}
+	return tables, options
+}
+
+// validateMongoFilters checks the filters against what mongodump supports: one
+// --collection, or any number of --excludeCollection, within a single database.
+func validateMongoFilters(spec model.BackupSpec, db string) error {
+	if err := validateCommonFilters(spec); err != nil {
+		return err
+	}
+	if len(spec.ExcludeTableData) > 0 {
+		return fmt.Errorf("backup.exclude-table-data is not supported for MongoDB")
+	}
+	if len(spec.Schemas) > 0 {
+		return fmt.Errorf("backup.schemas is not supported for MongoDB, use backup.database")
+	}
+	if len(spec.IncludeTables) == 0 && len(spec.ExcludeTables) == 0 {
+		return nil
+	}
+	if db == "" {
+		return fmt.Errorf("collection filters need a database in backup.database or the connection URI")
+	}
+	if len(spec.IncludeTables) > 1 {
+		return fmt.Errorf("mongodump dumps at most one collection, backup.include-tables lists %d", len(spec.IncludeTables))
+	}
+	if len(spec.IncludeTables) > 0 && len(spec.ExcludeTables) > 0 {
+		return fmt.Errorf("mongodump cannot combine backup.include-tables with backup.exclude-tables")
+	}
+	return nil
+}
+
+func mongoFilterArgs(spec model.BackupSpec) []string {
+	var args []string
+	for _, c := range spec.IncludeTables {
+		args = append(args, "--collection="+c)
+	}
+	for _, c := range spec.ExcludeTables {
+		args = append(args, "--excludeCollection="+c)
+	}
+	return args
+}
@@ -0,0 +1,114 @@
+package dumper
+
+import (
+	"reflect"
+	"testing"
+
+	"label-backup/internal/model"
+)
+
+func TestPostgresFilters(t *testing.T) {
+	spec := model.BackupSpec{
+		IncludeTables:    []string{"public.orders*"},
+		ExcludeTables:    []string{`"Audit.Log"`},
+		ExcludeTableData: []string{"public.events"},
+		Schemas:          []string{"public"},
+	}
+	if err := validatePostgresFilters(spec); err != nil {
+		t.Fatalf("validatePostgresFilters() error = %v", err)
+	}
+	want := []string{"-n", "public", "-t", "public.orders*", "-T", `"Audit.Log"`, "--exclude-table-data=public.events", "--strict-names"}
+	if got := postgresFilterArgs(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("postgresFilterArgs() = %v, want %v", got, want)
+	}
+
+	for _, bad := range []model.BackupSpec{
+		{IncludeTables: []string{""}},
+		{ExcludeTables: []string{`"unterminated`}},
+		{IncludeTables: []string{"a.b.c.d"}},
+		{Schemas: []string{"a.b.c"}},
+	} {
+		if err := validatePostgresFilters(bad); err == nil {
+			t.Errorf("validatePostgresFilters(%+v) accepted an invalid pattern", bad)
+		}
+	}
+}
+
+func TestMySQLFilters(t *testing.T) {
+	spec := model.BackupSpec{
+		IncludeTables: []string{"orders", "shop.customers"},
+		ExcludeTables: []string{"audit_log"},
+	}
+	if err := validateMySQLFilters(spec, "shop"); err != nil {
+		t.Fatalf("validateMySQLFilters() error = %v", err)
+	}
+	tables, options := mysqlFilterArgs(spec, "shop")
+	if !reflect.DeepEqual(tables, []string{"orders", "customers"}) {
+		t.Errorf("tables = %v", tables)
+	}
+	if !reflect.DeepEqual(options, []string{"--ignore-table=shop.audit_log"}) {
+		t.Errorf("options = %v", options)
+	}
+
+	for _, bad := range []model.BackupSpec{
+		{IncludeTables: []string{"log_%"}},
+		{ExcludeTables: []string{"other.table"}},
+		{ExcludeTableData: []string{"events"}},
+		{Schemas: []string{"public"}},
+	} {
+		if err := validateMySQLFilters(bad, "shop"); err == nil {
+			t.Errorf("validateMySQLFilters(%+v) accepted an unsupported filter", bad)
+		}
+	}
+}
+
+func TestMySQLFiltersAcrossDatabases(t *testing.T) {
+	spec := model.BackupSpec{Database: "*", ExcludeTables: []string{"audit_log", "shop.carts"}}
+	if err := validateMySQLFilters(spec, ""); err != nil {
+		t.Fatalf("validateMySQLFilters() error = %v", err)
+	}
+	if _, options := mysqlFilterArgs(spec, "shop"); !reflect.DeepEqual(options, []string{"--ignore-table=shop.audit_log", "--ignore-table=shop.carts"}) {
+		t.Errorf("options for shop = %v", options)
+	}
+	if _, options := mysqlFilterArgs(spec, "blog"); !reflect.DeepEqual(options, []string{"--ignore-table=blog.audit_log"}) {
+		t.Errorf("options for blog = %v", options)
+	}
+
+	all := model.BackupSpec{MySQLAllDatabases: true, ExcludeTables: []string{"shop.carts"}}
+	if _, options := mysqlFilterArgs(all, ""); !reflect.DeepEqual(options, []string{"--ignore-table=shop.carts"}) {
+		t.Errorf("options for all databases = %v", options)
+	}
+
+	for _, bad := range []model.BackupSpec{
+		{Database: "*", IncludeTables: []string{"orders"}},
+		{MySQLAllDatabases: true, ExcludeTables: []string{"audit_log"}},
+	} {
+		if err := validateMySQLFilters(bad, ""); err == nil {
+			t.Errorf("validateMySQLFilters(%+v) accepted an unsupported filter", bad)
+		}
+	}
+}
+
+func TestMongoFilters(t *testing.T) {
+	spec := model.BackupSpec{ExcludeTables: []string{"sessions", "logs"}}
+	if err := validateMongoFilters(spec, "app"); err != nil {
+		t.Fatalf("validateMongoFilters() error = %v", err)
+	}
+	want := []string{"--excludeCollection=sessions", "--excludeCollection=logs"}
+	if got := mongoFilterArgs(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("mongoFilterArgs() = %v, want %v", got, want)
+	}
+
+	for _, bad := range []struct {
+		spec model.BackupSpec
+		db   string
+	}{
+		{model.BackupSpec{IncludeTables: []string{"users", "orders"}}, "app"},
+		{model.BackupSpec{IncludeTables: []string{"users"}, ExcludeTables: []string{"logs"}}, "app"},
+		{model.BackupSpec{ExcludeTables: []string{"logs"}}, ""},
+	} {
+		if err := validateMongoFilters(bad.spec, bad.db); err == nil {
+			t.Errorf("validateMongoFilters(%+v, %q) accepted an unsupported filter", bad.spec, bad.db)
+		}
+	}
+}
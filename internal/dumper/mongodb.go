@@ -1,11 +1,9 @@
 package dumper
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"os/exec"
 	"strings"
 
 	"label-backup/internal/logger"
@@ -24,6 +22,18 @@ func init() {
 	RegisterDumperFactory(MongoDBDumperType, NewMongoDBDumper)
 }
 
+// MaskMongoURI hides the credentials part of a MongoDB URI for logging.
+func MaskMongoURI(uri string) string {
+	if strings.Contains(uri, "@") && (strings.HasPrefix(uri, "mongodb://") || strings.HasPrefix(uri, "mongodb+srv://")) {
+		schemaEnd := strings.Index(uri, "://")
+		credEnd := strings.Index(uri, "@")
+		if schemaEnd != -1 && credEnd != -1 && credEnd > schemaEnd+3 {
+			return uri[:schemaEnd+3] + "<credentials>" + uri[credEnd:]
+		}
+	}
+	return uri
+}
+
 func NewMongoDBDumper(spec model.BackupSpec) (Dumper, error) {
 	if spec.Type != MongoDBDumperType {
 		err := fmt.Errorf("invalid dumper type for mongodb: %s", spec.Type)
@@ -43,17 +53,13 @@ func (d *MongoDBDumper) Dump(ctx context.Context, spec model.BackupSpec, writer
 	args := []string{}
 	loggedArgs := []string{}
 
+	conn := spec.Conn
+	if spec.Mode == ModeExec {
+		conn = execMongoURI(conn)
+	}
 	if spec.Conn != "" {
-		args = append(args, fmt.Sprintf("--uri=%s", spec.Conn))
-		maskedURI := spec.Conn
-		if strings.Contains(maskedURI, "@") && (strings.HasPrefix(maskedURI, "mongodb://") || strings.HasPrefix(maskedURI, "mongodb+srv://")) {
-			schemaEnd := strings.Index(maskedURI, "://")
-			credEnd := strings.Index(maskedURI, "@")
-			if schemaEnd != -1 && credEnd != -1 && credEnd > schemaEnd+3 {
-				maskedURI = maskedURI[:schemaEnd+3] + "<credentials>" + maskedURI[credEnd:]
-			}
-		}
-		loggedArgs = append(loggedArgs, fmt.Sprintf("--uri=%s", maskedURI))
+		args = append(args, fmt.Sprintf("--uri=%s", conn))
+		loggedArgs = append(loggedArgs, fmt.Sprintf("--uri=%s", MaskMongoURI(conn)))
 	} else {
 		logger.Log.Error("MongoDB connection string (spec.Conn) is empty",
 			zap.String("containerID", spec.ContainerID),
@@ -87,19 +93,22 @@ func (d *MongoDBDumper) Dump(ctx context.Context, spec model.BackupSpec, writer
 		}
 	}
 
+	filterArgs := mongoFilterArgs(spec)
+	args = append(args, filterArgs...)
+	loggedArgs = append(loggedArgs, filterArgs...)
+
 	args = append(args, "--archive")
 	loggedArgs = append(loggedArgs, "--archive")
 
-	cmd := exec.CommandContext(ctx, "mongodump", args...)
-
 	logger.Log.Info("Executing mongodump",
 		zap.String("containerID", spec.ContainerID),
 		zap.String("command", "mongodump"),
+		zap.String("mode", spec.Mode),
 		zap.Strings("args", loggedArgs),
 		zap.String("targetDatabase", dbToDump),
 	)
 
-	return StreamAndGzip(ctx, cmd, writer)
+	return runDump(ctx, spec, dumpCommand{name: "mongodump", args: args}, writer)
 }
 
 func (d *MongoDBDumper) TestConnection(ctx context.Context, spec model.BackupSpec) error {
@@ -123,19 +132,27 @@ func (d *MongoDBDumper) TestConnection(ctx context.Context, spec model.BackupSpe
 		}
 	}
 
-	// Use mongodump for connection testing - just check if we can connect
-	cmd := exec.CommandContext(ctx, "mongodump", "--uri", spec.Conn, "--out", "/tmp", "--quiet")
+	if err := validateMongoFilters(spec, dbToTest); err != nil {
+		return fmt.Errorf("invalid collection filters: %w", err)
+	}
 
-	var stderrBuf bytes.Buffer
-	cmd.Stderr = &stderrBuf
+	// Use mongodump for connection testing - just check if we can connect
+	cmd := dumpCommand{name: "mongodump", args: []string{"--uri", spec.Conn, "--out", "/tmp", "--quiet"}}
+	switch spec.Mode {
+	case ModeExec:
+		// Do not write a dump into the database container; a ping is enough there
+		cmd = firstAvailable([]string{"mongosh", "mongo"}, []string{execMongoURI(spec.Conn), "--quiet", "--eval", "db.runCommand({ping: 1})"})
+	case ModeSidecar:
+		cmd = firstAvailable([]string{"mongosh", "mongo"}, []string{spec.Conn, "--quiet", "--eval", "db.runCommand({ping: 1})"})
+	}
 
 	logger.Log.Debug("Testing MongoDB connection",
 		zap.String("containerID", spec.ContainerID),
 		zap.String("database", dbToTest),
 	)
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("connection test failed for MongoDB: %w (stderr: %s)", err, stderrBuf.String())
+	if stderr, err := runCheck(ctx, spec, cmd); err != nil {
+		return fmt.Errorf("connection test failed for MongoDB: %w (stderr: %s)", err, stderr)
 	}
 
 	logger.Log.Debug("MongoDB connection test successful", zap.String("containerID", spec.ContainerID))
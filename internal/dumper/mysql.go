@@ -1,17 +1,15 @@
 package dumper
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"net/url"
-	"os"
-	"os/exec"
 	"strings"
 
 	"label-backup/internal/logger"
 	"label-backup/internal/model"
+	"label-backup/internal/writer"
 
 	"go.uber.org/zap"
 )
@@ -19,10 +17,11 @@ import (
 const MySQLDumperType = "mysql"
 
 type MySQLDumper struct {
-	spec model.BackupSpec
+	spec     model.BackupSpec
+	position *writer.BinlogPosition
 }
 
-type mysqlConnParams struct {
+type MySQLConnParams struct {
 	User     string
 	Password string
 	Host     string
@@ -31,8 +30,8 @@ type mysqlConnParams struct {
 	SSLMode  string
 }
 
-func parseMySQLURI(connStr string) (*mysqlConnParams, error) {
-	params := &mysqlConnParams{}
+func ParseMySQLURI(connStr string) (*MySQLConnParams, error) {
+	params := &MySQLConnParams{}
 
 	if !strings.HasPrefix(connStr, "mysql://") {
 		return nil, fmt.Errorf("invalid MySQL connection URI: must start with mysql://, got %s", connStr)
@@ -87,8 +86,8 @@ func NewMySQLDumper(spec model.BackupSpec) (Dumper, error) {
 	return &MySQLDumper{spec: spec}, nil
 }
 
-func (d *MySQLDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io.Writer) error {
-	params, err := parseMySQLURI(spec.Conn)
+func (d *MySQLDumper) Dump(ctx context.Context, spec model.BackupSpec, w io.Writer) error {
+	params, err := ParseMySQLURI(spec.Conn)
 	if err != nil {
 		logger.Log.Error("MySQL dump failed: could not parse connection URI",
 			zap.String("containerID", spec.ContainerID),
@@ -97,6 +96,9 @@ func (d *MySQLDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io
 		)
 		return fmt.Errorf("failed to parse MySQL connection URI '%s': %w", spec.Conn, err)
 	}
+	if spec.Mode == ModeExec {
+		params.Host = ExecHost
+	}
 
 	args := []string{}
 	loggedArgs := []string{} 
@@ -114,12 +116,6 @@ func (d *MySQLDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io
 		loggedArgs = append(loggedArgs, "--user="+params.User)
 	}
 
-	cmd := exec.CommandContext(ctx, "mariadb-dump", args...)
-
-	if params.Password != "" {
-		cmd.Env = append(os.Environ(), "MYSQL_PWD="+params.Password)
-	}
-
 	if strings.ToLower(params.SSLMode) == "disable" || strings.ToLower(params.SSLMode) == "disabled" {
 		args = append(args, "--ssl=0")
 		loggedArgs = append(loggedArgs, "--ssl=0")
@@ -136,7 +132,14 @@ func (d *MySQLDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io
 	args = append(args, "--skip-lock-tables")
 
 	dbToDump := ""
-	if spec.Database != "" { 
+	if spec.MySQLAllDatabases {
+		// --all-databases writes every schema, including the mysql schema with users and grants, as one object
+		_, ignoreArgs := mysqlFilterArgs(spec, "")
+		args = append(args, ignoreArgs...)
+		loggedArgs = append(loggedArgs, ignoreArgs...)
+		args = append(args, "--all-databases")
+		loggedArgs = append(loggedArgs, "--all-databases")
+	} else if spec.Database != "" { 
 		dbToDump = spec.Database
 	} else if params.DBName != "" { 
 		dbToDump = params.DBName
@@ -149,32 +152,75 @@ func (d *MySQLDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io
 		)
 		return err
 	}
-	args = append(args, dbToDump)
-	loggedArgs = append(loggedArgs, dbToDump)
+	if dbToDump != "" {
+		tables, ignoreArgs := mysqlFilterArgs(spec, dbToDump)
+		args = append(args, ignoreArgs...)
+		loggedArgs = append(loggedArgs, ignoreArgs...)
+		args = append(args, dbToDump)
+		loggedArgs = append(loggedArgs, dbToDump)
+		args = append(args, tables...)
+		loggedArgs = append(loggedArgs, tables...)
+	}
+
+	cmd := dumpCommand{name: "mariadb-dump", args: args}
+	if spec.MySQLBinlog {
+		cmd.args = append([]string{mariadbPositionOption}, args...)
+	}
+	if spec.Mode == ModeExec || spec.Mode == ModeSidecar {
+		// MySQL images only ship mysqldump, recent MariaDB images only mariadb-dump
+		var options [][]string
+		if spec.MySQLBinlog {
+			options = [][]string{{mariadbPositionOption}, {mysqlPositionOption}}
+		}
+		cmd = firstAvailableWithOptions([]string{"mariadb-dump", "mysqldump"}, options, args)
+	}
 
-	cmd = exec.CommandContext(ctx, "mariadb-dump", args...)
-	
 	// Set password via environment variable for security
 	if params.Password != "" {
-		cmd.Env = append(os.Environ(), "MYSQL_PWD="+params.Password)
+		cmd.env = []string{"MYSQL_PWD=" + params.Password}
 	}
 
 	logger.Log.Info("Executing mariadb-dump",
 		zap.String("containerID", spec.ContainerID),
 		zap.String("command", "mariadb-dump"),
+		zap.String("mode", spec.Mode),
 		zap.Strings("args", loggedArgs),
 		zap.String("targetDatabase", dbToDump),
+		zap.Bool("allDatabases", spec.MySQLAllDatabases),
+		zap.Bool("binlogPosition", spec.MySQLBinlog),
 		zap.String("parsedSSLModeFromURI", params.SSLMode),
 	)
 
-	return StreamAndGzip(ctx, cmd, writer)
+	d.position = nil
+	if !spec.MySQLBinlog {
+		return runDump(ctx, spec, cmd, w)
+	}
+	var position *writer.BinlogPosition
+	pw := newPositionWriter(w, func(r io.Reader) { position = scanBinlogPosition(r) })
+	err = runDump(ctx, spec, cmd, pw)
+	pw.Wait()
+	if err != nil {
+		return err
+	}
+	d.position = position
+	if d.position != nil {
+		d.position.Database = dbToDump
+	} else {
+		logger.Log.Warn("MySQL dump has no binary log position, it cannot be used for point-in-time recovery",
+			zap.String("containerID", spec.ContainerID),
+		)
+	}
+	return nil
 }
 
 func (d *MySQLDumper) TestConnection(ctx context.Context, spec model.BackupSpec) error {
-	params, err := parseMySQLURI(spec.Conn)
+	params, err := ParseMySQLURI(spec.Conn)
 	if err != nil {
 		return fmt.Errorf("failed to parse connection URI: %w", err)
 	}
+	if spec.Mode == ModeExec {
+		params.Host = ExecHost
+	}
 
 	args := []string{}
 	if params.Host != "" {
@@ -188,27 +234,39 @@ func (d *MySQLDumper) TestConnection(ctx context.Context, spec model.BackupSpec)
 	}
 	
 	dbToTest := ""
-	if spec.Database != "" {
+	if spec.Database != "" && !spec.MultiDatabase() {
 		dbToTest = spec.Database
 	} else if params.DBName != "" {
 		dbToTest = params.DBName
 	}
 	
+	if HasTableFilters(spec) {
+		// Filters of a backup spanning several databases are not tied to one of them
+		filterDB := dbToTest
+		if spec.MultiDatabase() || spec.MySQLAllDatabases {
+			filterDB = ""
+		} else if dbToTest == "" {
+			return fmt.Errorf("invalid table filters: no database specified in URI path or backup.database label")
+		}
+		if err := validateMySQLFilters(spec, filterDB); err != nil {
+			return fmt.Errorf("invalid table filters: %w", err)
+		}
+	}
+
 	if dbToTest != "" {
 		args = append(args, dbToTest)
 	}
 	
 	args = append(args, "-e", "SELECT 1;")
 
-	cmd := exec.CommandContext(ctx, "mysql", args...)
-
+	cmd := dumpCommand{name: "mysql", args: args}
+	if spec.Mode == ModeExec || spec.Mode == ModeSidecar {
+		cmd = firstAvailable([]string{"mariadb", "mysql"}, args)
+	}
 	if params.Password != "" {
-		cmd.Env = append(os.Environ(), "MYSQL_PWD="+params.Password)
+		cmd.env = []string{"MYSQL_PWD=" + params.Password}
 	}
 
-	var stderrBuf bytes.Buffer
-	cmd.Stderr = &stderrBuf
-
 	logger.Log.Debug("Testing MySQL connection",
 		zap.String("containerID", spec.ContainerID),
 		zap.String("host", params.Host),
@@ -217,8 +275,8 @@ func (d *MySQLDumper) TestConnection(ctx context.Context, spec model.BackupSpec)
 		zap.String("database", dbToTest),
 	)
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("connection test failed for MySQL: %w (stderr: %s)", err, stderrBuf.String())
+	if stderr, err := runCheck(ctx, spec, cmd); err != nil {
+		return fmt.Errorf("connection test failed for MySQL: %w (stderr: %s)", err, stderr)
 	}
 
 	logger.Log.Debug("MySQL connection test successful", zap.String("containerID", spec.ContainerID))
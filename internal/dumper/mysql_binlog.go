@@ -0,0 +1,63 @@
+package dumper
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"label-backup/internal/writer"
+)
+
+const (
+	// mariadbPositionOption makes mariadb-dump write the binary log position as a
+	// commented CHANGE MASTER statement at the top of the dump.
+	mariadbPositionOption = "--master-data=2"
+	// mysqlPositionOption is the same for mysqldump 8.0.26 and later, which writes
+	// a CHANGE REPLICATION SOURCE statement.
+	mysqlPositionOption = "--source-data=2"
+)
+
+var binlogPositionPattern = regexp.MustCompile(`(?:MASTER|SOURCE)_LOG_FILE='([^']+)',\s*(?:MASTER|SOURCE)_LOG_POS=(\d+)`)
+
+// BinlogPosition returns the binary log position of the last successful dump
+// taken with backup.mysql.binlog.
+func (d *MySQLDumper) BinlogPosition() *writer.BinlogPosition {
+	return d.position
+}
+
+// MySQLBinlogTool returns the binary log client found in PATH: mariadb-binlog in
+// recent MariaDB client packages, mysqlbinlog otherwise.
+func MySQLBinlogTool() string {
+	for _, name := range []string{"mariadb-binlog", "mysqlbinlog"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return name
+		}
+	}
+	return "mysqlbinlog"
+}
+
+// scanBinlogPosition reads a gzipped dump up to the CHANGE MASTER or CHANGE
+// REPLICATION SOURCE statement and returns the position it names.
+func scanBinlogPosition(r io.Reader) *writer.BinlogPosition {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil
+	}
+	scanner := bufio.NewScanner(io.LimitReader(gz, positionScanLimit))
+	scanner.Buffer(make([]byte, 64*1024), positionScanLimit)
+	for scanner.Scan() {
+		match := binlogPositionPattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		pos, err := strconv.ParseInt(match[2], 10, 64)
+		if err != nil {
+			return nil
+		}
+		return &writer.BinlogPosition{File: match[1], Position: pos}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package dumper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"label-backup/internal/writer"
+)
+
+func gzipDump(t *testing.T, dump string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(gw, dump); err != nil {
+		t.Fatal(err)
+	}
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestPositionWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		dump     string
+		wantFile string
+		wantPos  int64
+	}{
+		{
+			name:     "master data",
+			dump:     "-- MariaDB dump\n--\n-- CHANGE MASTER TO MASTER_LOG_FILE='mysql-bin.000003', MASTER_LOG_POS=385;\nCREATE TABLE t (id int);\n",
+			wantFile: "mysql-bin.000003",
+			wantPos:  385,
+		},
+		{
+			name:     "source data",
+			dump:     "-- MySQL dump\n-- CHANGE REPLICATION SOURCE TO SOURCE_LOG_FILE='binlog.000012', SOURCE_LOG_POS=157;\n",
+			wantFile: "binlog.000012",
+			wantPos:  157,
+		},
+		{
+			name: "no position",
+			dump: "-- MySQL dump\nCREATE TABLE t (id int);\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := gzipDump(t, tt.dump)
+			var out bytes.Buffer
+			var pos *writer.BinlogPosition
+			pw := newPositionWriter(&out, func(r io.Reader) { pos = scanBinlogPosition(r) })
+			// Written in small chunks, the way a dump streams in
+			for i := 0; i < len(data); i += 7 {
+				end := min(i+7, len(data))
+				if _, err := pw.Write(data[i:end]); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			pw.Wait()
+			if !bytes.Equal(out.Bytes(), data) {
+				t.Error("positionWriter changed the dump")
+			}
+			if tt.wantFile == "" {
+				if pos != nil {
+					t.Errorf("Position() = %+v, want nil", pos)
+				}
+				return
+			}
+			if pos == nil || pos.File != tt.wantFile || pos.Position != tt.wantPos {
+				t.Errorf("Position() = %+v, want %s:%d", pos, tt.wantFile, tt.wantPos)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package dumper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// mysqlListDatabasesQuery lists the user schemas, leaving out the ones the server
+// maintains itself.
+const mysqlListDatabasesQuery = "SELECT schema_name FROM information_schema.schemata WHERE schema_name NOT IN ('information_schema', 'performance_schema', 'mysql', 'sys') ORDER BY schema_name"
+
+// Parts splits a backup.database of "*" or a comma-separated list into one object
+// per schema, each dumped with mysqldump like a single-database backup.
+func (d *MySQLDumper) Parts(ctx context.Context, spec model.BackupSpec) ([]Part, error) {
+	if !spec.MultiDatabase() {
+		return nil, nil
+	}
+
+	databases := spec.DatabaseList()
+	if databases == nil {
+		var err error
+		databases, err = d.listDatabases(ctx, spec)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(databases) == 0 {
+		return nil, fmt.Errorf("no databases to back up on %s", ConnHost(spec))
+	}
+
+	parts := make([]Part, 0, len(databases))
+	for _, db := range databases {
+		partSpec := spec
+		partSpec.Database = db
+		parts = append(parts, Part{Name: db, Kind: PartKindDatabase, Database: db, Dumper: d, Spec: partSpec})
+	}
+
+	logger.Log.Info("MySQL backup split into parts",
+		zap.String("containerID", spec.ContainerID),
+		zap.Strings("databases", databases),
+	)
+	return parts, nil
+}
+
+func (d *MySQLDumper) listDatabases(ctx context.Context, spec model.BackupSpec) ([]string, error) {
+	params, err := ParseMySQLURI(spec.Conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection URI: %w", err)
+	}
+	if spec.Mode == ModeExec {
+		params.Host = ExecHost
+	}
+
+	args := []string{}
+	if params.Host != "" {
+		args = append(args, "-h", params.Host)
+	}
+	if params.Port != "" {
+		args = append(args, "-P", params.Port)
+	}
+	if params.User != "" {
+		args = append(args, "-u", params.User)
+	}
+	args = append(args, "-N", "-B", "-e", mysqlListDatabasesQuery)
+
+	cmd := dumpCommand{name: "mysql", args: args}
+	if spec.Mode == ModeExec || spec.Mode == ModeSidecar {
+		cmd = firstAvailable([]string{"mariadb", "mysql"}, args)
+	}
+	if params.Password != "" {
+		cmd.env = []string{"MYSQL_PWD=" + params.Password}
+	}
+
+	stdout, stderr, err := runOutput(ctx, spec, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MySQL databases: %w (stderr: %s)", err, stderr)
+	}
+
+	var databases []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if db := strings.TrimSpace(line); db != "" {
+			databases = append(databases, db)
+		}
+	}
+	return databases, nil
+}
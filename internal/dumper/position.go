@@ -0,0 +1,48 @@
+package dumper
+
+import (
+	"errors"
+	"io"
+)
+
+// positionScanLimit bounds how much of a dump is searched for the position it
+// is consistent with, which the tools write before any data.
+const positionScanLimit = 1 << 20
+
+var errPositionScanned = errors.New("position scan finished")
+
+// positionWriter passes a gzipped dump through to w and runs scan on the start
+// of it on the side, to read the log position the dump starts at.
+type positionWriter struct {
+	w       io.Writer
+	pw      *io.PipeWriter
+	done    chan struct{}
+	scanned bool
+}
+
+func newPositionWriter(w io.Writer, scan func(r io.Reader)) *positionWriter {
+	pr, pw := io.Pipe()
+	p := &positionWriter{w: w, pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		scan(pr)
+		// Fails the writes still to come, which stops feeding the scan
+		pr.CloseWithError(errPositionScanned)
+	}()
+	return p
+}
+
+func (p *positionWriter) Write(b []byte) (int, error) {
+	if !p.scanned {
+		if _, err := p.pw.Write(b); err != nil {
+			p.scanned = true
+		}
+	}
+	return p.w.Write(b)
+}
+
+// Wait ends the scan and waits for scan to return.
+func (p *positionWriter) Wait() {
+	p.pw.Close()
+	<-p.done
+}
@@ -1,13 +1,10 @@
 package dumper
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"net/url"
-	"os"
-	"os/exec"
 	"strings"
 
 	"label-backup/internal/logger"
@@ -22,7 +19,7 @@ type PostgresDumper struct {
 	spec model.BackupSpec
 }
 
-type postgresConnParams struct {
+type PostgresConnParams struct {
 	Host     string
 	Port     string
 	User     string
@@ -30,8 +27,8 @@ type postgresConnParams struct {
 	DBName   string
 }
 
-func parsePostgresURI(connStr string) (*postgresConnParams, error) {
-	params := &postgresConnParams{}
+func ParsePostgresURI(connStr string) (*PostgresConnParams, error) {
+	params := &PostgresConnParams{}
 
 	if !strings.HasPrefix(connStr, "postgresql://") && !strings.HasPrefix(connStr, "postgres://") {
 		return nil, fmt.Errorf("invalid PostgreSQL connection URI: must start with postgresql:// or postgres://, got %s", connStr)
@@ -88,7 +85,7 @@ func NewPostgresDumper(spec model.BackupSpec) (Dumper, error) {
 }
 
 func (d *PostgresDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io.Writer) error {
-	params, err := parsePostgresURI(spec.Conn)
+	params, err := ParsePostgresURI(spec.Conn)
 	if err != nil {
 		logger.Log.Error("PostgreSQL dump failed: could not parse connection string",
 			zap.String("containerID", spec.ContainerID),
@@ -97,6 +94,9 @@ func (d *PostgresDumper) Dump(ctx context.Context, spec model.BackupSpec, writer
 		)
 		return err
 	}
+	if spec.Mode == ModeExec {
+		params.Host = ExecHost
+	}
 
 	args := []string{}
 	loggedArgs := []string{}
@@ -114,8 +114,15 @@ func (d *PostgresDumper) Dump(ctx context.Context, spec model.BackupSpec, writer
 		loggedArgs = append(loggedArgs, "-U", params.User)
 	}
 
+	if spec.PgFormat == PgFormatDirectory {
+		return d.dumpDirectory(ctx, spec, params, writer)
+	}
+
 	args = append(args, "-Fc")
 	loggedArgs = append(loggedArgs, "-Fc")
+	filterArgs := postgresFilterArgs(spec)
+	args = append(args, filterArgs...)
+	loggedArgs = append(loggedArgs, filterArgs...)
 
 	if params.DBName != "" {
 		args = append(args, params.DBName)
@@ -129,28 +136,34 @@ func (d *PostgresDumper) Dump(ctx context.Context, spec model.BackupSpec, writer
 		return err
 	}
 	
-	cmd := exec.CommandContext(ctx, "pg_dump", args...)
-
+	cmd := dumpCommand{name: "pg_dump", args: args}
 	if params.Password != "" {
-		cmd.Env = append(os.Environ(), "PGPASSWORD="+params.Password)
+		cmd.env = []string{"PGPASSWORD=" + params.Password}
 	}
 
 	logger.Log.Info("Executing pg_dump",
 		zap.String("containerID", spec.ContainerID),
 		zap.String("command", "pg_dump"),
+		zap.String("mode", spec.Mode),
 		zap.Strings("args", loggedArgs),
 		zap.String("targetDatabase", params.DBName),
 		zap.Bool("pgpassword_set", params.Password != ""),
 	)
 
-	return StreamAndGzip(ctx, cmd, writer)
+	return runDump(ctx, spec, cmd, writer)
 } 
 
 func (d *PostgresDumper) TestConnection(ctx context.Context, spec model.BackupSpec) error {
-	params, err := parsePostgresURI(spec.Conn)
+	params, err := ParsePostgresURI(spec.Conn)
 	if err != nil {
 		return fmt.Errorf("failed to parse connection string: %w", err)
 	}
+	if err := validatePostgresFilters(spec); err != nil {
+		return fmt.Errorf("invalid table filters: %w", err)
+	}
+	if spec.Mode == ModeExec {
+		params.Host = ExecHost
+	}
 
 	args := []string{}
 	if params.Host != "" {
@@ -165,15 +178,11 @@ func (d *PostgresDumper) TestConnection(ctx context.Context, spec model.BackupSp
 	args = append(args, "-d", params.DBName)
 	args = append(args, "-c", "SELECT 1;")
 
-	cmd := exec.CommandContext(ctx, "psql", args...)
-	
+	cmd := dumpCommand{name: "psql", args: args}
 	if params.Password != "" {
-		cmd.Env = append(os.Environ(), "PGPASSWORD="+params.Password)
+		cmd.env = []string{"PGPASSWORD=" + params.Password}
 	}
 
-	var stderrBuf bytes.Buffer
-	cmd.Stderr = &stderrBuf
-
 	logger.Log.Debug("Testing PostgreSQL connection",
 		zap.String("containerID", spec.ContainerID),
 		zap.String("host", params.Host),
@@ -182,8 +191,8 @@ func (d *PostgresDumper) TestConnection(ctx context.Context, spec model.BackupSp
 		zap.String("database", params.DBName),
 	)
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("connection test failed for PostgreSQL: %w (stderr: %s)", err, stderrBuf.String())
+	if stderr, err := runCheck(ctx, spec, cmd); err != nil {
+		return fmt.Errorf("connection test failed for PostgreSQL: %w (stderr: %s)", err, stderr)
 	}
 
 	logger.Log.Debug("PostgreSQL connection test successful", zap.String("containerID", spec.ContainerID))
@@ -0,0 +1,153 @@
+package dumper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// PostgresGlobalsPart names the pg_dumpall --globals-only object of a cluster backup.
+const PostgresGlobalsPart = "globals"
+
+const postgresListDatabasesQuery = "SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate ORDER BY datname"
+
+// postgresGlobalsDumper dumps the roles and tablespaces that pg_dump leaves out,
+// as plain SQL.
+type postgresGlobalsDumper struct {
+	PostgresDumper
+}
+
+func postgresConnArgs(params *PostgresConnParams) []string {
+	var args []string
+	if params.Host != "" {
+		args = append(args, "-h", params.Host)
+	}
+	if params.Port != "" {
+		args = append(args, "-p", params.Port)
+	}
+	if params.User != "" {
+		args = append(args, "-U", params.User)
+	}
+	return args
+}
+
+func postgresCommand(name string, params *PostgresConnParams, args ...string) dumpCommand {
+	cmd := dumpCommand{name: name, args: append(postgresConnArgs(params), args...)}
+	if params.Password != "" {
+		cmd.env = []string{"PGPASSWORD=" + params.Password}
+	}
+	return cmd
+}
+
+// Parts splits a backup.database of "*" or a comma-separated list into a globals
+// object and one object per database. The database in the URI path is only used
+// to connect for listing databases and dumping globals.
+func (d *PostgresDumper) Parts(ctx context.Context, spec model.BackupSpec) ([]Part, error) {
+	if !spec.MultiDatabase() {
+		return nil, nil
+	}
+
+	databases := spec.DatabaseList()
+	if databases == nil {
+		var err error
+		databases, err = d.listDatabases(ctx, spec)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(databases) == 0 {
+		return nil, fmt.Errorf("no databases to back up on %s", ConnHost(spec))
+	}
+
+	parts := []Part{{
+		Name:   PostgresGlobalsPart,
+		Kind:   PartKindGlobals,
+		Dumper: &postgresGlobalsDumper{PostgresDumper: *d},
+		Spec:   spec,
+	}}
+	for _, db := range databases {
+		partSpec := spec
+		partSpec.Database = db
+		conn, err := postgresConnForDatabase(spec.Conn, db)
+		if err != nil {
+			return nil, err
+		}
+		partSpec.Conn = conn
+		parts = append(parts, Part{Name: db, Kind: PartKindDatabase, Database: db, Dumper: d, Spec: partSpec})
+	}
+
+	logger.Log.Info("PostgreSQL cluster backup split into parts",
+		zap.String("containerID", spec.ContainerID),
+		zap.Strings("databases", databases),
+	)
+	return parts, nil
+}
+
+func (d *PostgresDumper) listDatabases(ctx context.Context, spec model.BackupSpec) ([]string, error) {
+	params, err := ParsePostgresURI(spec.Conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+	if spec.Mode == ModeExec {
+		params.Host = ExecHost
+	}
+
+	cmd := postgresCommand("psql", params, "-d", params.DBName, "-At", "-c", postgresListDatabasesQuery)
+	stdout, stderr, err := runOutput(ctx, spec, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PostgreSQL databases: %w (stderr: %s)", err, stderr)
+	}
+
+	var databases []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if db := strings.TrimSpace(line); db != "" {
+			databases = append(databases, db)
+		}
+	}
+	return databases, nil
+}
+
+// postgresConnForDatabase points a PostgreSQL URI at another database.
+func postgresConnForDatabase(connStr, db string) (string, error) {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse PostgreSQL connection URI: %w", err)
+	}
+	u.Path = "/" + db
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+func (d *postgresGlobalsDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io.Writer) error {
+	params, err := ParsePostgresURI(spec.Conn)
+	if err != nil {
+		logger.Log.Error("PostgreSQL globals dump failed: could not parse connection string",
+			zap.String("containerID", spec.ContainerID),
+			zap.String("connectionString", spec.Conn),
+			zap.Error(err),
+		)
+		return err
+	}
+	if spec.Mode == ModeExec {
+		params.Host = ExecHost
+	}
+
+	cmd := postgresCommand("pg_dumpall", params, "-l", params.DBName, "--globals-only")
+
+	logger.Log.Info("Executing pg_dumpall --globals-only",
+		zap.String("containerID", spec.ContainerID),
+		zap.String("command", "pg_dumpall"),
+		zap.String("mode", spec.Mode),
+		zap.String("host", params.Host),
+		zap.Bool("pgpassword_set", params.Password != ""),
+	)
+
+	return runDump(ctx, spec, cmd, writer)
+}
@@ -0,0 +1,48 @@
+package dumper
+
+import (
+	"context"
+	"testing"
+
+	"label-backup/internal/model"
+)
+
+func TestPostgresParts(t *testing.T) {
+	d := &PostgresDumper{}
+	spec := model.BackupSpec{
+		Type:     PostgresDumperType,
+		Conn:     "postgresql://admin:secret@db:5432/postgres?sslmode=disable",
+		Database: "app, billing",
+	}
+
+	parts, err := d.Parts(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("Parts() error = %v", err)
+	}
+	if len(parts) != 3 {
+		t.Fatalf("Parts() returned %d parts, want 3", len(parts))
+	}
+	if parts[0].Name != PostgresGlobalsPart || parts[0].Kind != PartKindGlobals {
+		t.Errorf("first part = %s (%s), want the globals", parts[0].Name, parts[0].Kind)
+	}
+	if _, ok := parts[0].Dumper.(*postgresGlobalsDumper); !ok {
+		t.Errorf("globals part dumper = %T", parts[0].Dumper)
+	}
+
+	billing := parts[2]
+	if billing.Name != "billing" || billing.Database != "billing" || billing.Spec.Database != "billing" {
+		t.Errorf("database part = %+v", billing)
+	}
+	params, err := ParsePostgresURI(billing.Spec.Conn)
+	if err != nil {
+		t.Fatalf("part connection %q: %v", billing.Spec.Conn, err)
+	}
+	if params.DBName != "billing" || params.User != "admin" || params.Password != "secret" {
+		t.Errorf("part connection params = %+v", params)
+	}
+
+	single, err := d.Parts(context.Background(), model.BackupSpec{Type: PostgresDumperType, Conn: spec.Conn, Database: "app"})
+	if err != nil || single != nil {
+		t.Errorf("Parts() of a single database = %v, %v, want nil", single, err)
+	}
+}
@@ -0,0 +1,157 @@
+package dumper
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PgFormatCustom is the single-stream pg_dump -Fc archive.
+	PgFormatCustom = "custom"
+	// PgFormatDirectory dumps with pg_dump -Fd, which can use several jobs, and
+	// stores the directory as a tar.
+	PgFormatDirectory = "directory"
+
+	GlobalConfigKeyScratchDir = "BACKUP_SCRATCH_DIR"
+)
+
+// postgresDirectoryScript runs pg_dump -Fd into a temporary directory where the
+// dump tools run and writes the directory to stdout as a tar.
+const postgresDirectoryScript = `dir=$(mktemp -d) || exit 1
+trap 'rm -rf "$dir"' EXIT
+pg_dump -f "$dir/dump" "$@" && tar -C "$dir" -cf - dump`
+
+var scratchDir = os.TempDir()
+
+// SetScratchDir sets where directory-format dumps are staged in client mode. It
+// is called once at startup; an empty dir keeps the system temp directory.
+func SetScratchDir(dir string) {
+	if dir != "" {
+		scratchDir = dir
+	}
+}
+
+// dumpDirectory runs pg_dump -Fd -j with spec.PgJobs jobs and streams the
+// gzipped tar of the dump directory into w.
+func (d *PostgresDumper) dumpDirectory(ctx context.Context, spec model.BackupSpec, params *PostgresConnParams, w io.Writer) error {
+	jobs := spec.PgJobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	cmd := postgresCommand("pg_dump", params, append([]string{"-Fd", "-j", strconv.Itoa(jobs)}, postgresFilterArgs(spec)...)...)
+
+	logger.Log.Info("Executing pg_dump in directory format",
+		zap.String("containerID", spec.ContainerID),
+		zap.String("command", "pg_dump"),
+		zap.String("mode", spec.Mode),
+		zap.Int("jobs", jobs),
+		zap.String("targetDatabase", params.DBName),
+		zap.Bool("pgpassword_set", params.Password != ""),
+	)
+
+	if spec.Mode == ModeExec || spec.Mode == ModeSidecar {
+		script := dumpCommand{name: "sh", args: append([]string{"-c", postgresDirectoryScript, "sh"}, cmd.args...), env: cmd.env}
+		script.args = append(script.args, params.DBName)
+		return runDump(ctx, spec, script, w)
+	}
+
+	if err := writer.CheckDiskSpace(scratchDir); err != nil {
+		return fmt.Errorf("scratch space check failed for %s: %w", scratchDir, err)
+	}
+	dir, err := os.MkdirTemp(scratchDir, "label-backup-pg-")
+	if err != nil {
+		return fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			logger.Log.Error("Failed to remove scratch directory", zap.String("path", dir), zap.Error(err))
+		}
+	}()
+
+	args := append(cmd.args, "-f", filepath.Join(dir, "dump"), params.DBName)
+	pgDump := exec.CommandContext(ctx, cmd.name, args...)
+	if len(cmd.env) > 0 {
+		pgDump.Env = append(os.Environ(), cmd.env...)
+	}
+	var stderrBuf bytes.Buffer
+	pgDump.Stderr = &stderrBuf
+	if err := pgDump.Run(); err != nil {
+		return fmt.Errorf("dump command 'pg_dump' failed (stderr: %s): %w", stderrBuf.String(), err)
+	}
+
+	gw := gzip.NewWriter(w)
+	if err := tarDirectory(ctx, dir, gw); err != nil {
+		gw.Close()
+		return fmt.Errorf("failed to archive dump directory: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("failed to finish gzip stream: %w", err)
+	}
+
+	logger.Log.Info("Directory-format dump streamed", zap.String("containerID", spec.ContainerID))
+	return nil
+}
+
+// tarDirectory writes the contents of dir to w as a tar, with paths relative to dir.
+func tarDirectory(ctx context.Context, dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if path == dir {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
@@ -0,0 +1,50 @@
+package dumper
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTarDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "dump"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dump", "toc.dat"), []byte("toc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := tarDirectory(context.Background(), dir, &buf); err != nil {
+		t.Fatalf("tarDirectory() error = %v", err)
+	}
+
+	tr := tar.NewReader(&buf)
+	var names []string
+	var content string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("reading tar: %v", err)
+		}
+		names = append(names, header.Name)
+		if header.Name == "dump/toc.dat" {
+			data, _ := io.ReadAll(tr)
+			content = string(data)
+		}
+	}
+	if len(names) != 2 || names[0] != "dump/" || names[1] != "dump/toc.dat" {
+		t.Errorf("tar entries = %v, want [dump/ dump/toc.dat]", names)
+	}
+	if content != "toc" {
+		t.Errorf("dump/toc.dat content = %q", content)
+	}
+}
@@ -0,0 +1,30 @@
+package dumper
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+func TestScanBackupLabel(t *testing.T) {
+	label := "START WAL LOCATION: 0/2000028 (file 000000010000000000000002)\nCHECKPOINT LOCATION: 0/2000060\nBACKUP METHOD: streamed\n"
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	_ = tw.WriteHeader(&tar.Header{Name: "backup_label", Typeflag: tar.TypeReg, Mode: 0o600, Size: int64(len(label))})
+	_, _ = tw.Write([]byte(label))
+	_ = tw.WriteHeader(&tar.Header{Name: "PG_VERSION", Typeflag: tar.TypeReg, Mode: 0o600, Size: 3})
+	_, _ = tw.Write([]byte("16\n"))
+	_ = tw.Close()
+	_ = gw.Close()
+
+	pos := scanBackupLabel(bytes.NewReader(buf.Bytes()))
+	if pos == nil || pos.StartLSN != "0/2000028" || pos.StartSegment != "000000010000000000000002" {
+		t.Fatalf("scanBackupLabel() = %+v, want 0/2000028 in 000000010000000000000002", pos)
+	}
+
+	if pos := scanBackupLabel(bytes.NewReader(gzipDump(t, "not a tar"))); pos != nil {
+		t.Errorf("scanBackupLabel() of a dump without backup_label = %+v, want nil", pos)
+	}
+}
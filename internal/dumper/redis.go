@@ -1,12 +1,10 @@
 package dumper
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"net/url"
-	"os/exec"
 	"strings"
 
 	"label-backup/internal/logger"
@@ -21,15 +19,15 @@ type RedisDumper struct {
 	spec model.BackupSpec
 }
 
-type redisConnParams struct {
+type RedisConnParams struct {
 	Host     string
 	Port     string
 	Password string
 	DBNum    string
 }
 
-func parseRedisConn(connStr string) (*redisConnParams, error) {
-	params := &redisConnParams{Port: "6379"}
+func ParseRedisConn(connStr string) (*RedisConnParams, error) {
+	params := &RedisConnParams{Port: "6379"}
 
 	if strings.HasPrefix(connStr, "redis://") {
 		u, err := url.Parse(connStr)
@@ -92,7 +90,7 @@ func NewRedisDumper(spec model.BackupSpec) (Dumper, error) {
 }
 
 func (d *RedisDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io.Writer) error {
-	params, err := parseRedisConn(spec.Conn)
+	params, err := ParseRedisConn(spec.Conn)
 	if err != nil {
 		logger.Log.Error("Failed to parse Redis connection string",
 			zap.String("containerID", spec.ContainerID),
@@ -101,6 +99,9 @@ func (d *RedisDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io
 		)
 		return fmt.Errorf("failed to parse Redis connection string '%s': %w", spec.Conn, err)
 	}
+	if spec.Mode == ModeExec {
+		params.Host = ExecHost
+	}
 
 	args := []string{}
 	if params.Host != "" {
@@ -120,8 +121,6 @@ func (d *RedisDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io
 
 	args = append(args, "--rdb", "-")
 
-	cmd := exec.CommandContext(ctx, "redis-cli", args...)
-
 	safeArgsToLog := make([]string, len(args))
 	for i, arg := range args {
 		if i > 0 && args[i-1] == "-a" {
@@ -134,17 +133,21 @@ func (d *RedisDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io
 	logger.Log.Info("Executing redis-cli",
 		zap.String("containerID", spec.ContainerID),
 		zap.String("command", "redis-cli"),
+		zap.String("mode", spec.Mode),
 		zap.Strings("args", safeArgsToLog),
 	)
 
-	return StreamAndGzip(ctx, cmd, writer)
+	return runDump(ctx, spec, dumpCommand{name: "redis-cli", args: args}, writer)
 } 
 
 func (d *RedisDumper) TestConnection(ctx context.Context, spec model.BackupSpec) error {
-	params, err := parseRedisConn(spec.Conn)
+	params, err := ParseRedisConn(spec.Conn)
 	if err != nil {
 		return fmt.Errorf("failed to parse connection string: %w", err)
 	}
+	if spec.Mode == ModeExec {
+		params.Host = ExecHost
+	}
 
 	args := []string{}
 	if params.Host != "" {
@@ -163,10 +166,7 @@ func (d *RedisDumper) TestConnection(ctx context.Context, spec model.BackupSpec)
 	}
 	args = append(args, "ping")
 
-	cmd := exec.CommandContext(ctx, "redis-cli", args...)
-
-	var stderrBuf bytes.Buffer
-	cmd.Stderr = &stderrBuf
+	cmd := dumpCommand{name: "redis-cli", args: args}
 
 	logger.Log.Debug("Testing Redis connection",
 		zap.String("containerID", spec.ContainerID),
@@ -175,8 +175,8 @@ func (d *RedisDumper) TestConnection(ctx context.Context, spec model.BackupSpec)
 		zap.String("database", spec.Database),
 	)
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("redis connection test failed: %w (stderr: %s)", err, stderrBuf.String())
+	if stderr, err := runCheck(ctx, spec, cmd); err != nil {
+		return fmt.Errorf("redis connection test failed: %w (stderr: %s)", err, stderr)
 	}
 
 	logger.Log.Debug("Redis connection test successful", zap.String("containerID", spec.ContainerID))
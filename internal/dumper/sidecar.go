@@ -0,0 +1,166 @@
+package dumper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"label-backup/internal/dockerexec"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	"go.uber.org/zap"
+)
+
+// ModeSidecar runs the dump tools in a short-lived container started from
+// backup.sidecar.image, on the database container's network.
+const ModeSidecar = "sidecar"
+
+// sidecarResult is how a sidecar command ended.
+type sidecarResult struct {
+	exitCode int
+	stderr   string
+}
+
+// runSidecar runs c in a new container from spec.SidecarImage joined to the
+// network of the labeled container, copies its stdout to stdout and removes the
+// container afterwards.
+func runSidecar(ctx context.Context, spec model.BackupSpec, c dumpCommand, stdout io.Writer) (*sidecarResult, error) {
+	if dockerClient == nil {
+		return nil, fmt.Errorf("backup.mode=sidecar needs a Docker client")
+	}
+	if spec.SidecarImage == "" {
+		return nil, fmt.Errorf("no image for the dump sidecar, set backup.sidecar.image")
+	}
+
+	shortID := spec.ContainerID
+	if len(shortID) > 12 {
+		shortID = shortID[:12]
+	}
+	name := fmt.Sprintf("label-backup-dump-%s-%d", shortID, time.Now().UnixNano())
+	networkName := dockerexec.PrimaryNetwork(ctx, dockerClient, spec.ContainerID)
+
+	config := &container.Config{
+		Image:        spec.SidecarImage,
+		Entrypoint:   []string{c.name},
+		Cmd:          c.args,
+		Env:          c.env,
+		AttachStdout: true,
+		AttachStderr: true,
+		Labels:       map[string]string{"label-backup.sidecar": spec.ContainerID},
+	}
+	// The dump must not end up in the host's container logs
+	hostConfig := &container.HostConfig{LogConfig: container.LogConfig{Type: "none"}}
+	var networkingConfig *network.NetworkingConfig
+	if networkName != "" {
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{networkName: {}},
+		}
+	}
+
+	resp, err := dockerClient.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dump sidecar from image %s (the image must be available locally): %w", spec.SidecarImage, err)
+	}
+	defer removeSidecar(resp.ID)
+
+	logger.Log.Info("Created dump sidecar",
+		zap.String("containerID", spec.ContainerID),
+		zap.String("sidecar", name),
+		zap.String("image", spec.SidecarImage),
+		zap.String("network", networkName),
+		zap.String("command", c.name),
+	)
+
+	// Attaching before the start streams stdout byte for byte; the log driver
+	// would mangle binary dumps and is disabled above
+	attach, err := dockerClient.ContainerAttach(ctx, resp.ID, container.AttachOptions{Stream: true, Stdout: true, Stderr: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach to dump sidecar: %w", err)
+	}
+	defer attach.Close()
+
+	waitCh, waitErrCh := dockerClient.ContainerWait(ctx, resp.ID, container.WaitConditionNextExit)
+	if err := dockerClient.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to start dump sidecar: %w", err)
+	}
+
+	// The hijacked connection does not observe ctx, so close it on cancellation
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			attach.Close()
+		case <-done:
+		}
+	}()
+
+	var stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(stdout, &stderr, attach.Reader); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to read dump sidecar output: %w", err)
+	}
+
+	select {
+	case status := <-waitCh:
+		if status.Error != nil {
+			return nil, fmt.Errorf("failed to wait for dump sidecar: %s", status.Error.Message)
+		}
+		return &sidecarResult{exitCode: int(status.StatusCode), stderr: stderr.String()}, nil
+	case err := <-waitErrCh:
+		return nil, fmt.Errorf("failed to wait for dump sidecar: %w", err)
+	}
+}
+
+func removeSidecar(containerID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := dockerClient.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+		logger.Log.Error("Failed to remove dump sidecar", zap.String("sidecarID", containerID), zap.Error(err))
+		return
+	}
+	logger.Log.Debug("Removed dump sidecar", zap.String("sidecarID", containerID))
+}
+
+// StreamSidecarAndGzip runs c in a dump sidecar and gzips its stdout into
+// destWriter, reporting failures like StreamAndGzip.
+func StreamSidecarAndGzip(ctx context.Context, spec model.BackupSpec, c dumpCommand, destWriter io.Writer) error {
+	logFields := []zap.Field{
+		zap.String("containerID", spec.ContainerID),
+		zap.String("image", spec.SidecarImage),
+		zap.String("command", c.name),
+	}
+
+	gw := gzip.NewWriter(destWriter)
+	defer gw.Close()
+
+	res, err := runSidecar(ctx, spec, c, gw)
+	if err != nil {
+		logger.Log.Error("StreamSidecarAndGzip: sidecar failed", append(logFields, zap.Error(err))...)
+		return err
+	}
+	if res.exitCode != 0 {
+		logger.Log.Error("StreamSidecarAndGzip: dump command failed",
+			append(logFields,
+				zap.Int("exitCode", res.exitCode),
+				zap.String("stderr", res.stderr),
+			)...)
+		return fmt.Errorf("dump command '%s' failed in sidecar (stderr: %s): exit code %d", c.name, res.stderr, res.exitCode)
+	}
+	if res.stderr != "" {
+		logger.Log.Warn("StreamSidecarAndGzip: dump command completed with messages on stderr",
+			append(logFields, zap.String("stderr", res.stderr))...)
+	}
+
+	logger.Log.Info("StreamSidecarAndGzip: successfully streamed and gzipped output", logFields...)
+	return nil
+}
@@ -0,0 +1,101 @@
+package dumper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"go.uber.org/zap"
+)
+
+const SQLiteDumperType = "sqlite"
+
+// sqliteBackupScript snapshots the database file $1 with the online backup API
+// of the sqlite3 shell (.backup), which is consistent even while the application
+// writes, and prints the snapshot. A missing file is an error; sqlite3 would
+// otherwise create an empty database.
+const sqliteBackupScript = `test -f "$1" || { echo "database file $1 not found" >&2; exit 1; }
+tmp=$(mktemp) || exit 1
+trap 'rm -f "$tmp"' EXIT
+sqlite3 "$1" ".backup '$tmp'" && cat "$tmp"`
+
+const sqliteTestScript = `test -f "$1" || { echo "database file $1 not found" >&2; exit 1; }
+sqlite3 "$1" 'SELECT count(*) FROM sqlite_master;' >/dev/null`
+
+type SQLiteDumper struct {
+	spec model.BackupSpec
+}
+
+func init() {
+	RegisterDumperFactory(SQLiteDumperType, NewSQLiteDumper)
+}
+
+// ParseSQLitePath returns the database file from backup.conn, given either as
+// an absolute path or as sqlite:///absolute/path.
+func ParseSQLitePath(connStr string) (string, error) {
+	p := strings.TrimPrefix(connStr, "sqlite://")
+	if !path.IsAbs(p) {
+		return "", fmt.Errorf("invalid SQLite connection '%s': must be an absolute path or sqlite:///absolute/path", connStr)
+	}
+	return path.Clean(p), nil
+}
+
+func NewSQLiteDumper(spec model.BackupSpec) (Dumper, error) {
+	if spec.Type != SQLiteDumperType {
+		err := fmt.Errorf("invalid dumper type for sqlite: %s", spec.Type)
+		logger.Log.Error("Failed to create new SQLiteDumper",
+			zap.String("expectedType", SQLiteDumperType),
+			zap.String("providedType", spec.Type),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &SQLiteDumper{spec: spec}, nil
+}
+
+func (d *SQLiteDumper) Dump(ctx context.Context, spec model.BackupSpec, writer io.Writer) error {
+	dbPath, err := ParseSQLitePath(spec.Conn)
+	if err != nil {
+		logger.Log.Error("SQLite dump failed: could not parse connection string",
+			zap.String("containerID", spec.ContainerID),
+			zap.String("connectionString", spec.Conn),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	logger.Log.Info("Executing sqlite3 .backup",
+		zap.String("containerID", spec.ContainerID),
+		zap.String("command", "sqlite3"),
+		zap.String("mode", spec.Mode),
+		zap.String("path", dbPath),
+	)
+
+	cmd := dumpCommand{name: "sh", args: []string{"-c", sqliteBackupScript, "sh", dbPath}}
+	return runDump(ctx, spec, cmd, writer)
+}
+
+func (d *SQLiteDumper) TestConnection(ctx context.Context, spec model.BackupSpec) error {
+	dbPath, err := ParseSQLitePath(spec.Conn)
+	if err != nil {
+		return fmt.Errorf("failed to parse connection string: %w", err)
+	}
+
+	logger.Log.Debug("Testing SQLite database file",
+		zap.String("containerID", spec.ContainerID),
+		zap.String("path", dbPath),
+	)
+
+	cmd := dumpCommand{name: "sh", args: []string{"-c", sqliteTestScript, "sh", dbPath}}
+	if stderr, err := runCheck(ctx, spec, cmd); err != nil {
+		return fmt.Errorf("connection test failed for SQLite: %w (stderr: %s)", err, stderr)
+	}
+
+	logger.Log.Debug("SQLite connection test successful", zap.String("containerID", spec.ContainerID))
+	return nil
+}
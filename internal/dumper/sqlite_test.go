@@ -0,0 +1,71 @@
+package dumper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"label-backup/internal/model"
+)
+
+func TestParseSQLitePath(t *testing.T) {
+	tests := []struct {
+		conn    string
+		want    string
+		wantErr bool
+	}{
+		{conn: "/data/gitea.db", want: "/data/gitea.db"},
+		{conn: "sqlite:///var/lib/grafana/grafana.db", want: "/var/lib/grafana/grafana.db"},
+		{conn: "sqlite://data/app.db", wantErr: true},
+		{conn: "app.db", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSQLitePath(tt.conn)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSQLitePath(%q) = (%q, %v), want %q (error %v)", tt.conn, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSQLiteDumper(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	dbPath := filepath.Join(t.TempDir(), "app.db")
+	if out, err := exec.Command("sqlite3", dbPath, "PRAGMA journal_mode=WAL; CREATE TABLE t (v TEXT); INSERT INTO t VALUES ('x');").CombinedOutput(); err != nil {
+		t.Fatalf("failed to create test database: %v: %s", err, out)
+	}
+
+	spec := model.BackupSpec{Type: SQLiteDumperType, Conn: "sqlite://" + dbPath, ContainerID: "abc123"}
+	d, err := GetDumper(spec)
+	if err != nil {
+		t.Fatalf("GetDumper() error = %v", err)
+	}
+	if err := d.TestConnection(context.Background(), spec); err != nil {
+		t.Fatalf("TestConnection() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := d.Dump(context.Background(), spec, &buf); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("Dump() output is not gzipped: %v", err)
+	}
+	snapshot, _ := io.ReadAll(gz)
+	if !strings.HasPrefix(string(snapshot), "SQLite format 3\x00") {
+		t.Errorf("Dump() output is not a SQLite database (%d bytes)", len(snapshot))
+	}
+
+	missing := spec
+	missing.Conn = filepath.Join(t.TempDir(), "missing.db")
+	if err := d.TestConnection(context.Background(), missing); err == nil {
+		t.Error("TestConnection() succeeded for a missing database file")
+	}
+}
@@ -0,0 +1,119 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"label-backup/internal/model"
+
+	"filippo.io/age"
+)
+
+func TestAgeRoundTrip(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("GenerateX25519Identity() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	identityFile := filepath.Join(dir, "identity.txt")
+	if err := os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write identity file: %v", err)
+	}
+
+	encryptor, err := NewAgeEncryptor([]string{identity.Recipient().String()}, "")
+	if err != nil {
+		t.Fatalf("NewAgeEncryptor() error = %v", err)
+	}
+
+	plaintext := bytes.Repeat([]byte("label-backup "), 20000)
+	encrypted, err := encryptor.Encrypt(context.Background(), bytes.NewReader(plaintext))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	ciphertext, err := io.ReadAll(encrypted)
+	if err != nil {
+		t.Fatalf("reading ciphertext: %v", err)
+	}
+	if err := encrypted.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	decryptor, err := NewAgeDecryptor(identityFile, "")
+	if err != nil {
+		t.Fatalf("NewAgeDecryptor() error = %v", err)
+	}
+	decrypted, err := decryptor.Decrypt(context.Background(), bytes.NewReader(ciphertext))
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	got, err := io.ReadAll(decrypted)
+	if err != nil {
+		t.Fatalf("reading plaintext: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("decrypted data does not match original plaintext")
+	}
+}
+
+func TestAgePassphraseRoundTrip(t *testing.T) {
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(passphraseFile, []byte("correct horse battery staple\n"), 0600); err != nil {
+		t.Fatalf("failed to write passphrase file: %v", err)
+	}
+
+	encryptor, err := NewAgeEncryptor(nil, passphraseFile)
+	if err != nil {
+		t.Fatalf("NewAgeEncryptor() error = %v", err)
+	}
+	encrypted, err := encryptor.Encrypt(context.Background(), bytes.NewReader([]byte("secret dump")))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	ciphertext, _ := io.ReadAll(encrypted)
+	if err := encrypted.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	decryptor, err := NewAgeDecryptor("", passphraseFile)
+	if err != nil {
+		t.Fatalf("NewAgeDecryptor() error = %v", err)
+	}
+	decrypted, err := decryptor.Decrypt(context.Background(), bytes.NewReader(ciphertext))
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	got, _ := io.ReadAll(decrypted)
+	if string(got) != "secret dump" {
+		t.Errorf("Decrypt() = %q, want %q", got, "secret dump")
+	}
+}
+
+func TestResolveType(t *testing.T) {
+	tests := []struct {
+		name       string
+		encType    string
+		recipients []string
+		global     map[string]string
+		expected   string
+	}{
+		{name: "explicit label wins", encType: "age", global: map[string]string{GlobalConfigKeyGPGPublicKeyPath: "/k"}, expected: "age"},
+		{name: "label recipients imply age", recipients: []string{"age1x"}, global: map[string]string{GlobalConfigKeyGPGPublicKeyPath: "/k"}, expected: "age"},
+		{name: "gpg key configured", global: map[string]string{GlobalConfigKeyGPGPublicKeyPath: "/k"}, expected: "gpg"},
+		{name: "global age recipients", global: map[string]string{GlobalConfigKeyAgeRecipients: "age1x"}, expected: "age"},
+		{name: "nothing configured", global: map[string]string{}, expected: "gpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := model.BackupSpec{EncryptionType: tt.encType, EncryptRecipients: tt.recipients}
+			if got := ResolveType(spec, tt.global); got != tt.expected {
+				t.Errorf("ResolveType() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
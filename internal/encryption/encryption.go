@@ -7,17 +7,131 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"strings"
+	"sync"
 
 	"label-backup/internal/logger"
+	"label-backup/internal/model"
 
 	"go.uber.org/zap"
 )
 
+const GlobalConfigKeyGPGPublicKeyPath = "GPG_PUBLIC_KEY_PATH"
+
+const GPGEncryptionType = "gpg"
+
+type Encryptor interface {
+	Encrypt(ctx context.Context, input io.Reader) (io.ReadCloser, error)
+	IsEnabled() bool
+	Type() string
+	// KeyInfo describes the key material used, for recording in backup metadata.
+	KeyInfo() string
+	GetEncryptedExtension() string
+}
+
+type Decryptor interface {
+	Decrypt(ctx context.Context, input io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	_ Encryptor = (*GPGEncryptor)(nil)
+	_ Encryptor = (*AgeEncryptor)(nil)
+	_ Decryptor = (*GPGDecryptor)(nil)
+	_ Decryptor = (*AgeDecryptor)(nil)
+)
+
+// ResolveType picks the encryption method for a spec: an explicit label wins,
+// per-container age recipients imply age, otherwise whichever global key is configured.
+func ResolveType(spec model.BackupSpec, globalConfig map[string]string) string {
+	if spec.EncryptionType != "" {
+		return spec.EncryptionType
+	}
+	if len(spec.EncryptRecipients) > 0 {
+		return AgeEncryptionType
+	}
+	if globalConfig[GlobalConfigKeyGPGPublicKeyPath] != "" {
+		return GPGEncryptionType
+	}
+	if globalConfig[GlobalConfigKeyAgeRecipients] != "" || globalConfig[GlobalConfigKeyAgePassphraseFile] != "" {
+		return AgeEncryptionType
+	}
+	return GPGEncryptionType
+}
+
+func GetEncryptor(spec model.BackupSpec, globalConfig map[string]string) (Encryptor, error) {
+	switch encType := ResolveType(spec, globalConfig); encType {
+	case GPGEncryptionType:
+		keyPath := globalConfig[GlobalConfigKeyGPGPublicKeyPath]
+		if keyPath == "" {
+			return nil, fmt.Errorf("gpg encryption requested but %s is not configured", GlobalConfigKeyGPGPublicKeyPath)
+		}
+		return sharedGPGEncryptor(keyPath)
+	case AgeEncryptionType:
+		recipients := spec.EncryptRecipients
+		if len(recipients) == 0 {
+			recipients = ParseRecipientList(globalConfig[GlobalConfigKeyAgeRecipients])
+		}
+		passphraseFile := ""
+		if len(recipients) == 0 {
+			passphraseFile = globalConfig[GlobalConfigKeyAgePassphraseFile]
+		}
+		return NewAgeEncryptor(recipients, passphraseFile)
+	default:
+		return nil, fmt.Errorf("unsupported encryption type: %s", encType)
+	}
+}
+
+var (
+	gpgEncryptorsMu sync.Mutex
+	gpgEncryptors   = make(map[string]*GPGEncryptor)
+)
+
+// sharedGPGEncryptor returns the encryptor for the global GPG public key, which is
+// validated once rather than on every backup. Failures are not cached, so a key
+// that is fixed later is picked up by the next backup.
+func sharedGPGEncryptor(keyPath string) (*GPGEncryptor, error) {
+	gpgEncryptorsMu.Lock()
+	defer gpgEncryptorsMu.Unlock()
+	if encryptor, ok := gpgEncryptors[keyPath]; ok {
+		return encryptor, nil
+	}
+	encryptor, err := NewGPGEncryptor(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	gpgEncryptors[keyPath] = encryptor
+	return encryptor, nil
+}
+
+// GetDecryptor returns the decryptor for backups written with the given encryption type.
+func GetDecryptor(encType string, globalConfig map[string]string) (Decryptor, error) {
+	switch encType {
+	case GPGEncryptionType:
+		return NewGPGDecryptor()
+	case AgeEncryptionType:
+		return NewAgeDecryptor(globalConfig[GlobalConfigKeyAgeIdentityFile], globalConfig[GlobalConfigKeyAgePassphraseFile])
+	default:
+		return nil, fmt.Errorf("unsupported encryption type: %s", encType)
+	}
+}
+
+// TypeFromExtension infers the encryption type from an object name, or "" if it is not encrypted.
+func TypeFromExtension(objectName string) string {
+	switch {
+	case strings.HasSuffix(objectName, ".gpg"):
+		return GPGEncryptionType
+	case strings.HasSuffix(objectName, ".age"):
+		return AgeEncryptionType
+	}
+	return ""
+}
+
 type EncryptedReader struct {
-	reader io.Reader
-	cmd    *exec.Cmd
-	stderr *bytes.Buffer
-	ctx    context.Context
+	reader  io.Reader
+	cmd     *exec.Cmd
+	stderr  *bytes.Buffer
+	ctx     context.Context
+	copyErr chan error
 }
 
 func (r *EncryptedReader) Read(p []byte) (n int, err error) {
@@ -25,11 +139,19 @@ func (r *EncryptedReader) Read(p []byte) (n int, err error) {
 }
 
 func (r *EncryptedReader) Close() error {
+	// Drain whatever the consumer left unread so gpg can exit before Wait
+	_, _ = io.Copy(io.Discard, r.reader)
+
+	copyErr := <-r.copyErr
+
 	// Wait for command to finish and check for errors
 	err := r.cmd.Wait()
 	if err != nil {
 		return fmt.Errorf("GPG encryption failed: %w (stderr: %s)", err, r.stderr.String())
 	}
+	if copyErr != nil {
+		return fmt.Errorf("GPG encryption input copy failed: %w", copyErr)
+	}
 	logger.Log.Debug("GPG encryption completed successfully")
 	return nil
 }
@@ -95,26 +217,32 @@ func (e *GPGEncryptor) Encrypt(ctx context.Context, input io.Reader) (io.ReadClo
 		return nil, fmt.Errorf("failed to start GPG command: %w", err)
 	}
 
+	copyErr := make(chan error, 1)
+
 	// Copy input to stdin with context cancellation
 	go func() {
 		defer stdin.Close()
 		select {
 		case <-ctx.Done():
 			logger.Log.Debug("GPG encryption cancelled during input copy")
+			copyErr <- ctx.Err()
 			return
 		default:
-			if _, err := io.Copy(stdin, input); err != nil {
+			_, err := io.Copy(stdin, input)
+			if err != nil {
 				logger.Log.Error("Failed to copy data to GPG stdin", zap.Error(err))
 			}
+			copyErr <- err
 		}
 	}()
 
 	logger.Log.Debug("GPG encryption started")
 	return &EncryptedReader{
-		reader: stdout,
-		cmd:    cmd,
-		stderr: stderr,
-		ctx:    ctx,
+		reader:  stdout,
+		cmd:     cmd,
+		stderr:  stderr,
+		ctx:     ctx,
+		copyErr: copyErr,
 	}, nil
 }
 
@@ -122,9 +250,66 @@ func (e *GPGEncryptor) IsEnabled() bool {
 	return e.enabled
 }
 
+func (e *GPGEncryptor) Type() string {
+	return GPGEncryptionType
+}
+
+func (e *GPGEncryptor) PublicKeyPath() string {
+	return e.publicKeyPath
+}
+
+func (e *GPGEncryptor) KeyInfo() string {
+	return e.publicKeyPath
+}
+
 func (e *GPGEncryptor) GetEncryptedExtension() string {
 	if e.enabled {
 		return ".gpg"
 	}
 	return ""
 }
+
+// GPGDecryptor decrypts with the private keys in the gpg keyring (GNUPGHOME).
+type GPGDecryptor struct{}
+
+func NewGPGDecryptor() (*GPGDecryptor, error) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		return nil, fmt.Errorf("GPG not found in PATH: %w", err)
+	}
+	return &GPGDecryptor{}, nil
+}
+
+type gpgDecryptedReader struct {
+	io.Reader
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+func (r *gpgDecryptedReader) Close() error {
+	_, _ = io.Copy(io.Discard, r.Reader)
+	if err := r.cmd.Wait(); err != nil {
+		return fmt.Errorf("GPG decryption failed: %w (stderr: %s)", err, r.stderr.String())
+	}
+	logger.Log.Debug("GPG decryption completed successfully")
+	return nil
+}
+
+func (d *GPGDecryptor) Decrypt(ctx context.Context, input io.Reader) (io.ReadCloser, error) {
+	cmd := exec.CommandContext(ctx, "gpg", "--decrypt", "--batch", "--yes", "--quiet")
+	cmd.Stdin = input
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start GPG command: %w", err)
+	}
+
+	logger.Log.Debug("GPG decryption started")
+	return &gpgDecryptedReader{Reader: stdout, cmd: cmd, stderr: stderr}, nil
+}
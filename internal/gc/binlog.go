@@ -0,0 +1,65 @@
+package gc
+
+import (
+	"context"
+	"strings"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/writer"
+
+	"go.uber.org/zap"
+)
+
+// collectBinlogs deletes the binary logs archived for this spec that come before
+// the position of the oldest dump left after this run, which no remaining dump
+// can be rolled forward with. Files of another binary log base name are kept. It
+// returns the number of objects deleted, the bytes freed and the keys that failed
+// to delete.
+func (r *Runner) collectBinlogs(ctx context.Context, logObjects []writer.BackupObjectMeta, units []backupUnit, removed map[string]bool) (int, int64, []string) {
+	prefix := writer.BinlogKeyPrefix(r.spec)
+	var files []writer.BackupObjectMeta
+	for _, obj := range logObjects {
+		if strings.HasPrefix(obj.Key, prefix) {
+			files = append(files, obj)
+		}
+	}
+	if len(files) == 0 {
+		return 0, 0, nil
+	}
+
+	start, ok := r.oldestPosition(ctx, units, removed, func(metadata *writer.BackupMetadata) string {
+		if metadata.Binlog == nil {
+			return ""
+		}
+		return metadata.Binlog.File
+	})
+	base, first, valid := writer.SplitBinlogName(start)
+	if !ok || !valid {
+		logger.Log.Info("GC: Keeping all archived binary logs, no remaining dump records a binary log position",
+			zap.String("containerID", r.spec.ContainerID),
+			zap.String("archive", prefix),
+			zap.Int("files", len(files)),
+		)
+		return 0, 0, nil
+	}
+
+	deleteCount := 0
+	var totalSizeFreed int64
+	var failedDeletes []string
+	for _, obj := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		objBase, seq, ok := writer.SplitBinlogName(writer.LogFileName(obj.Key))
+		if !ok || objBase != base || seq >= first {
+			continue
+		}
+		deleted, freed, err := r.deleteObjects(ctx, []writer.BackupObjectMeta{obj}, "before the oldest dump at "+start)
+		deleteCount += deleted
+		totalSizeFreed += freed
+		if err != nil {
+			failedDeletes = append(failedDeletes, obj.Key)
+		}
+	}
+	return deleteCount, totalSizeFreed, failedDeletes
+}
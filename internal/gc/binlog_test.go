@@ -0,0 +1,90 @@
+package gc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+)
+
+func TestRunGCKeepsBinlogsOfSafeguardedDump(t *testing.T) {
+	old := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	binlogs := "shop/binlog/mysql-shop/"
+	mock := &mockBackupWriter{
+		objects: []writer.BackupObjectMeta{
+			{Key: "shop/mysql-shop-20250101020000.dump.gz", LastModified: old},
+			{Key: "shop/mysql-shop-20250101020000.dump.gz.metadata.json", LastModified: old},
+			{Key: "shop/mysql-shop-20250102020000.dump.gz", LastModified: old},
+			{Key: "shop/mysql-shop-20250102020000.dump.gz.metadata.json", LastModified: old},
+			{Key: binlogs + "mysql-bin.000001.gz", LastModified: old},
+			{Key: binlogs + "mysql-bin.000002.gz", LastModified: old},
+			{Key: binlogs + "mysql-bin.000003.gz.age", LastModified: old},
+		},
+		contents: map[string]string{
+			"shop/mysql-shop-20250101020000.dump.gz.metadata.json": `{"success": true, "binlog": {"file": "mysql-bin.000001", "position": 4}}`,
+			"shop/mysql-shop-20250102020000.dump.gz.metadata.json": `{"success": true, "binlog": {"file": "mysql-bin.000002", "position": 157}}`,
+		},
+	}
+	spec := model.BackupSpec{ContainerID: "test", Type: "mysql", Database: "shop", Prefix: "shop"}
+
+	runner, err := NewRunner(spec, mock, 7*24*time.Hour, false, 1, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if err := runner.RunGC(context.Background()); err != nil {
+		t.Fatalf("RunGC() error = %v", err)
+	}
+
+	// The safeguarded dump is past retention, but still needs its binary logs
+	want := []string{
+		"shop/mysql-shop-20250102020000.dump.gz",
+		"shop/mysql-shop-20250102020000.dump.gz.metadata.json",
+		binlogs + "mysql-bin.000002.gz",
+		binlogs + "mysql-bin.000003.gz.age",
+	}
+	if got := remainingKeys(mock); !reflect.DeepEqual(got, want) {
+		t.Errorf("remaining objects = %v, want %v", got, want)
+	}
+}
+
+func TestRunGCWithPolicyCollectsBinlogs(t *testing.T) {
+	binlogs := "shop/binlog/mysql-shop/"
+	mock := &mockBackupWriter{
+		objects: []writer.BackupObjectMeta{
+			{Key: "shop/mysql-shop-20250101020000.dump.gz"},
+			{Key: "shop/mysql-shop-20250101020000.dump.gz.metadata.json"},
+			{Key: "shop/mysql-shop-20250102020000.dump.gz"},
+			{Key: "shop/mysql-shop-20250102020000.dump.gz.metadata.json"},
+			{Key: binlogs + "mysql-bin.000001.gz"},
+			{Key: binlogs + "mysql-bin.000002.gz"},
+			{Key: binlogs + "mysql-bin.000003.gz"},
+			{Key: binlogs + "other-bin.000001.gz"},
+		},
+		contents: map[string]string{
+			"shop/mysql-shop-20250101020000.dump.gz.metadata.json": `{"success": true, "binlog": {"file": "mysql-bin.000001", "position": 4}}`,
+			"shop/mysql-shop-20250102020000.dump.gz.metadata.json": `{"success": true, "binlog": {"file": "mysql-bin.000003", "position": 157}}`,
+		},
+	}
+	spec := model.BackupSpec{ContainerID: "test", Type: "mysql", Database: "shop", Prefix: "shop", RetentionKeepLast: 1}
+
+	runner, err := NewRunner(spec, mock, 7*24*time.Hour, false, 0, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if err := runner.RunGC(context.Background()); err != nil {
+		t.Fatalf("RunGC() error = %v", err)
+	}
+
+	want := []string{
+		"shop/mysql-shop-20250102020000.dump.gz",
+		"shop/mysql-shop-20250102020000.dump.gz.metadata.json",
+		binlogs + "mysql-bin.000003.gz",
+		binlogs + "other-bin.000001.gz",
+	}
+	if got := remainingKeys(mock); !reflect.DeepEqual(got, want) {
+		t.Errorf("remaining objects = %v, want %v", got, want)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"label-backup/internal/logger"
 	"label-backup/internal/model"
+	"label-backup/internal/webhook"
 	"label-backup/internal/writer"
 
 	"go.uber.org/zap"
@@ -16,10 +17,15 @@ type Runner struct {
 	spec              model.BackupSpec
 	backupWriter      writer.BackupWriter
 	effectiveRetention time.Duration
+	policy            RetentionPolicy
 	dryRun            bool
+	keepSuccessful    int
+	webhookSender     webhook.WebhookSender
 }
 
-func NewRunner(spec model.BackupSpec, bw writer.BackupWriter, globalRetentionPeriod time.Duration, dryRun bool) (*Runner, error) {
+// NewRunner creates a GC runner for one spec. keepSuccessful is the number of newest
+// successful backups that are never deleted; webhookSender may be nil.
+func NewRunner(spec model.BackupSpec, bw writer.BackupWriter, globalRetentionPeriod time.Duration, dryRun bool, keepSuccessful int, webhookSender webhook.WebhookSender) (*Runner, error) {
 	retentionToUse := globalRetentionPeriod
 	if spec.Retention > 0 {
 		retentionToUse = spec.Retention
@@ -34,7 +40,13 @@ func NewRunner(spec model.BackupSpec, bw writer.BackupWriter, globalRetentionPer
 		)
 	}
 
-	if retentionToUse <= 0 {
+	policy := PolicyFromSpec(spec)
+	if policy.Enabled() {
+		logger.Log.Info("GC: Using GFS retention policy",
+			zap.String("containerID", spec.ContainerID),
+			zap.String("policy", policy.String()),
+		)
+	} else if retentionToUse <= 0 {
 		logger.Log.Warn("GC: Effective retention period is zero or negative. No garbage collection will be performed for this spec.",
 			zap.String("containerID", spec.ContainerID),
 			zap.Duration("effectiveRetention", retentionToUse),
@@ -46,6 +58,7 @@ func NewRunner(spec model.BackupSpec, bw writer.BackupWriter, globalRetentionPer
 		zap.String("prefix", spec.Prefix),
 		zap.Duration("effectiveRetention", retentionToUse),
 		zap.Bool("dryRun", dryRun),
+		zap.Int("keepSuccessful", keepSuccessful),
 		zap.String("writerType", bw.Type()),
 	)
 
@@ -53,12 +66,15 @@ func NewRunner(spec model.BackupSpec, bw writer.BackupWriter, globalRetentionPer
 		spec:              spec,
 		backupWriter:      bw,
 		effectiveRetention: retentionToUse,
+		policy:            policy,
 		dryRun:            dryRun,
+		keepSuccessful:    keepSuccessful,
+		webhookSender:     webhookSender,
 	}, nil
 }
 
 func (r *Runner) RunGC(ctx context.Context) error {
-	if r.effectiveRetention <= 0 {
+	if r.effectiveRetention <= 0 && !r.policy.Enabled() {
 		logger.Log.Info("GC: Skipping run as effective retention period is not positive.",
 			zap.String("containerID", r.spec.ContainerID),
 			zap.Duration("effectiveRetention", r.effectiveRetention),
@@ -94,8 +110,26 @@ func (r *Runner) RunGC(ctx context.Context) error {
 		return nil
 	}
 
+	objects, logObjects := splitLogs(objects)
+	objects = r.ownObjects(objects)
+	protected := r.protectedObjects(ctx, objects)
+	units, orphans := pairObjects(objects)
+	if len(orphans) > 0 {
+		logger.Log.Warn("GC: Found metadata sidecars without a backup; enable GC_SWEEP_ORPHANS to remove them",
+			zap.String("containerID", r.spec.ContainerID),
+			zap.String("prefix", r.spec.Prefix),
+			zap.Int("orphanedSidecars", len(orphans)),
+		)
+	}
+
+	if r.policy.Enabled() {
+		return r.runPolicyGC(ctx, units, logObjects, protected)
+	}
+
+	removed := make(map[string]bool)
 	deleteCount := 0
 	var failedDeletes []string
+	var safeguarded []string
 	var totalSizeFreed int64
 	now := time.Now().UTC()
 	cutoffDate := now.Add(-r.effectiveRetention)
@@ -103,11 +137,15 @@ func (r *Runner) RunGC(ctx context.Context) error {
 	logger.Log.Info("GC: Object scan details",
 		zap.String("containerID", r.spec.ContainerID),
 		zap.Int("objectCount", len(objects)),
+		zap.Int("backupCount", len(units)),
 		zap.String("prefix", r.spec.Prefix),
 		zap.String("cutoffDate", cutoffDate.Format(time.RFC3339)),
 	)
 
-	for _, obj := range objects {
+	// A unit's age is its backup's age; the sidecar is rewritten by restore drills
+	// and must not keep an expired backup alive or expire before it.
+	for _, unit := range units {
+		obj := unit.Backup
 		if ctx.Err() != nil {
 			logger.Log.Warn("GC run cancelled during object iteration",
 				zap.String("containerID", r.spec.ContainerID),
@@ -118,73 +156,185 @@ func (r *Runner) RunGC(ctx context.Context) error {
 			)
 			return ctx.Err()
 		}
-		
-		if obj.LastModified.Before(cutoffDate) {
-			logger.Log.Info("GC: Object qualifies for deletion",
+
+		if !obj.LastModified.Before(cutoffDate) {
+			logger.Log.Debug("GC: Object is within retention period. Keeping.",
 				zap.String("containerID", r.spec.ContainerID),
 				zap.String("key", obj.Key),
 				zap.Time("lastModified", obj.LastModified),
-				zap.Int64("size", obj.Size),
 			)
+			continue
+		}
+
+		if protected[obj.Key] {
+			logger.Log.Warn("GC: Object is past retention but kept by the last-good-backup safeguard",
+				zap.String("containerID", r.spec.ContainerID),
+				zap.String("key", obj.Key),
+				zap.Time("lastModified", obj.LastModified),
+			)
+			safeguarded = append(safeguarded, obj.Key)
+			continue
+		}
+
+		logger.Log.Info("GC: Object qualifies for deletion",
+			zap.String("containerID", r.spec.ContainerID),
+			zap.String("key", obj.Key),
+			zap.Time("lastModified", obj.LastModified),
+			zap.Int64("size", obj.Size),
+			zap.Bool("hasSidecar", unit.Sidecar != nil),
+		)
+		deleted, freed, err := r.deleteObjects(ctx, unit.objects(), "past retention")
+		deleteCount += deleted
+		totalSizeFreed += freed
+		if err != nil {
+			failedDeletes = append(failedDeletes, obj.Key)
+		} else {
+			removed[obj.Key] = true
+		}
+	}
+
+	deleted, freed, failedLogs := r.collectLogs(ctx, logObjects, units, removed)
+	deleteCount += deleted
+	totalSizeFreed += freed
+	failedDeletes = append(failedDeletes, failedLogs...)
+
+	statusMsg := "deleted"
+	if r.dryRun {
+		statusMsg = "that would be deleted (dry run)"
+	}
+	
+	logger.Log.Info("GC run completed",
+		zap.String("containerID", r.spec.ContainerID),
+		zap.String("prefix", r.spec.Prefix),
+		zap.Int("objectsConsidered", len(objects)),
+		zap.String("status", statusMsg),
+		zap.Int("objectsAffected", deleteCount),
+		zap.Int64("totalSizeFreed", totalSizeFreed),
+		zap.Int("failedDeletes", len(failedDeletes)),
+	)
+
+	r.notifySafeguard(safeguarded)
+	
+	if len(failedDeletes) > 0 {
+		return fmt.Errorf("GC completed with %d failures: %v", len(failedDeletes), failedDeletes)
+	}
+	
+	return nil
+}
+
+// runPolicyGC applies the GFS policy to this spec's backups. Timestamps come from the
+// object keys, so only keys produced by GenerateObjectName are considered; metadata
+// sidecars share the fate of their backup.
+func (r *Runner) runPolicyGC(ctx context.Context, units []backupUnit, logObjects []writer.BackupObjectMeta, protected map[string]bool) error {
+	unitsByKey := make(map[string]backupUnit)
+	var backups []policyBackup
+
+	for _, unit := range units {
+		ts, ok := writer.ParseObjectTimestamp(unit.Backup.Key)
+		if !ok {
+			logger.Log.Debug("GC: Object key has no backup timestamp. Keeping.",
+				zap.String("containerID", r.spec.ContainerID),
+				zap.String("key", unit.Backup.Key),
+			)
+			continue
+		}
+		unitsByKey[unit.Backup.Key] = unit
+		backups = append(backups, policyBackup{Key: unit.Backup.Key, Timestamp: ts})
+	}
+
+	decisions := r.policy.Evaluate(backups, time.Now().UTC())
+
+	removed := make(map[string]bool)
+	keptCount := 0
+	deleteCount := 0
+	var failedDeletes []string
+	var safeguarded []string
+	var totalSizeFreed int64
+
+	for _, b := range backups {
+		if ctx.Err() != nil {
+			logger.Log.Warn("GC run cancelled during policy evaluation",
+				zap.String("containerID", r.spec.ContainerID),
+				zap.String("prefix", r.spec.Prefix),
+				zap.Error(ctx.Err()),
+			)
+			return ctx.Err()
+		}
+
+		if rules := decisions[b.Key]; len(rules) > 0 {
+			keptCount++
 			if r.dryRun {
-				logger.Log.Info("[DryRun] GC: Would delete object",
+				logger.Log.Info("[DryRun] GC: Would retain object",
 					zap.String("containerID", r.spec.ContainerID),
-					zap.String("key", obj.Key),
-					zap.Int64("size", obj.Size),
+					zap.String("key", b.Key),
+					zap.Time("backupTime", b.Timestamp),
+					zap.Strings("retainedBy", rules),
 				)
-				deleteCount++
-				totalSizeFreed += obj.Size
 			} else {
-				deleteCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-				err := r.backupWriter.DeleteObject(deleteCtx, obj.Key)
-				cancel()
-				if err != nil {
-					logger.Log.Error("GC: Failed to delete object",
-						zap.String("containerID", r.spec.ContainerID),
-						zap.String("key", obj.Key),
-						zap.Error(err),
-					)
-					failedDeletes = append(failedDeletes, obj.Key)
-					continue
-				}
-				logger.Log.Info("GC: Successfully deleted object",
+				logger.Log.Debug("GC: Object retained by policy",
 					zap.String("containerID", r.spec.ContainerID),
-					zap.String("key", obj.Key),
-					zap.Int64("size", obj.Size),
+					zap.String("key", b.Key),
+					zap.Strings("retainedBy", rules),
 				)
-				deleteCount++
-				totalSizeFreed += obj.Size
-				
-				// Rate limiting - small delay between deletes
-				time.Sleep(100 * time.Millisecond)
 			}
-		} else {
-			logger.Log.Debug("GC: Object is within retention period. Keeping.",
+			continue
+		}
+
+		if protected[b.Key] {
+			keptCount++
+			logger.Log.Warn("GC: Backup released by policy but kept by the last-good-backup safeguard",
 				zap.String("containerID", r.spec.ContainerID),
-				zap.String("key", obj.Key),
-				zap.Time("lastModified", obj.LastModified),
+				zap.String("key", b.Key),
+				zap.Time("backupTime", b.Timestamp),
+				zap.String("releasedBy", r.policy.String()),
 			)
+			safeguarded = append(safeguarded, b.Key)
+			continue
+		}
+
+		logger.Log.Info("GC: Backup not selected by any retention rule",
+			zap.String("containerID", r.spec.ContainerID),
+			zap.String("key", b.Key),
+			zap.Time("backupTime", b.Timestamp),
+			zap.String("releasedBy", r.policy.String()),
+		)
+		deleted, freed, err := r.deleteObjects(ctx, unitsByKey[b.Key].objects(), "released by "+r.policy.String())
+		deleteCount += deleted
+		totalSizeFreed += freed
+		if err != nil {
+			failedDeletes = append(failedDeletes, b.Key)
+		} else {
+			removed[b.Key] = true
 		}
 	}
 
+	deleted, freed, failedLogs := r.collectLogs(ctx, logObjects, units, removed)
+	deleteCount += deleted
+	totalSizeFreed += freed
+	failedDeletes = append(failedDeletes, failedLogs...)
+
 	statusMsg := "deleted"
 	if r.dryRun {
 		statusMsg = "that would be deleted (dry run)"
 	}
-	
+
 	logger.Log.Info("GC run completed",
 		zap.String("containerID", r.spec.ContainerID),
 		zap.String("prefix", r.spec.Prefix),
-		zap.Int("objectsConsidered", len(objects)),
+		zap.String("policy", r.policy.String()),
+		zap.Int("backupsConsidered", len(backups)),
+		zap.Int("backupsRetained", keptCount),
 		zap.String("status", statusMsg),
 		zap.Int("objectsAffected", deleteCount),
 		zap.Int64("totalSizeFreed", totalSizeFreed),
 		zap.Int("failedDeletes", len(failedDeletes)),
 	)
-	
+
+	r.notifySafeguard(safeguarded)
+
 	if len(failedDeletes) > 0 {
 		return fmt.Errorf("GC completed with %d failures: %v", len(failedDeletes), failedDeletes)
 	}
-	
+
 	return nil
-} 
\ No newline at end of file
+}
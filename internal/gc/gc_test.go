@@ -2,16 +2,20 @@ package gc
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
 	"label-backup/internal/model"
+	"label-backup/internal/webhook"
 	"label-backup/internal/writer"
 )
 
 type mockBackupWriter struct {
-	objects []writer.BackupObjectMeta
+	objects  []writer.BackupObjectMeta
+	contents map[string]string
 }
 
 func (m *mockBackupWriter) Type() string {
@@ -23,13 +27,26 @@ func (m *mockBackupWriter) Write(ctx context.Context, objectName string, reader
 }
 
 func (m *mockBackupWriter) ReadObject(ctx context.Context, objectName string) (io.ReadCloser, error) {
-	return io.NopCloser(io.Reader(nil)), nil
+	if content, ok := m.contents[objectName]; ok {
+		return io.NopCloser(strings.NewReader(content)), nil
+	}
+	return nil, fmt.Errorf("object %s not found", objectName)
 }
 
 func (m *mockBackupWriter) ListObjects(ctx context.Context, prefix string) ([]writer.BackupObjectMeta, error) {
-	return m.objects, nil
+	return append([]writer.BackupObjectMeta(nil), m.objects...), nil
+}
+
+type mockWebhookSender struct {
+	payloads []webhook.NotificationPayload
+}
+
+func (m *mockWebhookSender) Enqueue(payload webhook.NotificationPayload, backupSpec model.BackupSpec) {
+	m.payloads = append(m.payloads, payload)
 }
 
+func (m *mockWebhookSender) Stop() {}
+
 func (m *mockBackupWriter) DeleteObject(ctx context.Context, key string) error {
 	for i, obj := range m.objects {
 		if obj.Key == key {
@@ -55,9 +72,9 @@ func TestRunGC(t *testing.T) {
 		{
 			name: "delete old objects",
 			objects: []writer.BackupObjectMeta{
-				{Key: "old1.dump.gz", LastModified: oldTime},
-				{Key: "old2.dump.gz", LastModified: oldTime},
-				{Key: "recent.dump.gz", LastModified: recentTime},
+				{Key: "test-prefix/postgres-app-20250101020000.dump.gz", LastModified: oldTime},
+				{Key: "test-prefix/postgres-app-20250102020000.dump.gz", LastModified: oldTime},
+				{Key: "test-prefix/postgres-app-20250110020000.dump.gz", LastModified: recentTime},
 			},
 			retention:      7 * 24 * time.Hour,
 			expectedDeletes: 2,
@@ -66,7 +83,7 @@ func TestRunGC(t *testing.T) {
 		{
 			name: "dry run mode",
 			objects: []writer.BackupObjectMeta{
-				{Key: "old1.dump.gz", LastModified: oldTime},
+				{Key: "test-prefix/postgres-app-20250101020000.dump.gz", LastModified: oldTime},
 			},
 			retention:      7 * 24 * time.Hour,
 			expectedDeletes: 1,
@@ -75,8 +92,8 @@ func TestRunGC(t *testing.T) {
 		{
 			name: "no objects to delete",
 			objects: []writer.BackupObjectMeta{
-				{Key: "recent1.dump.gz", LastModified: recentTime},
-				{Key: "recent2.dump.gz", LastModified: recentTime},
+				{Key: "test-prefix/postgres-app-20250109020000.dump.gz", LastModified: recentTime},
+				{Key: "test-prefix/postgres-app-20250110020000.dump.gz", LastModified: recentTime},
 			},
 			retention:      7 * 24 * time.Hour,
 			expectedDeletes: 0,
@@ -91,10 +108,12 @@ func TestRunGC(t *testing.T) {
 
 			spec := model.BackupSpec{
 				ContainerID: "test-container",
+				Type:        "postgres",
+				Database:    "app",
 				Prefix:      "test-prefix",
 			}
 
-			runner, err := NewRunner(spec, mockWriter, tt.retention, tt.dryRun)
+			runner, err := NewRunner(spec, mockWriter, tt.retention, tt.dryRun, 0, nil)
 			if err != nil {
 				t.Fatalf("NewRunner() error = %v", err)
 			}
@@ -113,3 +132,82 @@ func TestRunGC(t *testing.T) {
 		})
 	}
 }
+
+func TestRunGCKeepsLastSuccessfulBackup(t *testing.T) {
+	old := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	mock := &mockBackupWriter{
+		objects: []writer.BackupObjectMeta{
+			{Key: "db/postgres-app-20250103020000.dump.gz", LastModified: old},
+			{Key: "db/postgres-app-20250103020000.dump.gz.metadata.json", LastModified: old},
+			{Key: "db/postgres-app-20250102020000.dump.gz", LastModified: old},
+			{Key: "db/postgres-app-20250102020000.dump.gz.metadata.json", LastModified: old},
+			{Key: "db/postgres-app-20250101020000.dump.gz", LastModified: old},
+			{Key: "db/postgres-app-20250101020000.dump.gz.metadata.json", LastModified: old},
+		},
+		contents: map[string]string{
+			"db/postgres-app-20250103020000.dump.gz.metadata.json": `{"success": false}`,
+			"db/postgres-app-20250102020000.dump.gz.metadata.json": `{"success": true}`,
+			"db/postgres-app-20250101020000.dump.gz.metadata.json": `{"success": true}`,
+		},
+	}
+	sender := &mockWebhookSender{}
+	spec := model.BackupSpec{ContainerID: "test", Type: "postgres", Database: "app", Prefix: "db"}
+
+	runner, err := NewRunner(spec, mock, 7*24*time.Hour, false, 1, sender)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if err := runner.RunGC(context.Background()); err != nil {
+		t.Fatalf("RunGC() error = %v", err)
+	}
+
+	var remaining []string
+	for _, obj := range mock.objects {
+		remaining = append(remaining, obj.Key)
+	}
+	want := "db/postgres-app-20250102020000.dump.gz,db/postgres-app-20250102020000.dump.gz.metadata.json"
+	if got := strings.Join(remaining, ","); got != want {
+		t.Errorf("remaining objects = %s, want %s", got, want)
+	}
+	if len(sender.payloads) != 1 || sender.payloads[0].Event != webhook.EventGCSafeguard {
+		t.Errorf("expected one %s webhook, got %+v", webhook.EventGCSafeguard, sender.payloads)
+	}
+}
+
+func TestRunGCLeavesOtherSpecsInSharedBucket(t *testing.T) {
+	old := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	mock := &mockBackupWriter{
+		objects: []writer.BackupObjectMeta{
+			{Key: "postgres-app-20250102020000.dump.gz", LastModified: old},
+			{Key: "postgres-app-20250102020000.dump.gz.metadata.json", LastModified: old},
+			{Key: "postgres-app-20250101020000.dump.gz", LastModified: old},
+			{Key: "mysql-shop-20250101020000.dump.gz", LastModified: old},
+			{Key: "mysql-shop-20250101020000.dump.gz.metadata.json", LastModified: old},
+		},
+		contents: map[string]string{
+			"postgres-app-20250102020000.dump.gz.metadata.json": `{"success": true}`,
+			"mysql-shop-20250101020000.dump.gz.metadata.json":   `{"success": true}`,
+		},
+	}
+	app := model.BackupSpec{ContainerID: "app", Type: "postgres", Database: "app"}
+
+	// shop's last good backup is protected by shop's safeguard only, so app
+	// must not expire it even though it is past app's retention
+	runner, err := NewRunner(app, mock, 7*24*time.Hour, false, 1, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if err := runner.RunGC(context.Background()); err != nil {
+		t.Fatalf("RunGC() error = %v", err)
+	}
+
+	var remaining []string
+	for _, obj := range mock.objects {
+		remaining = append(remaining, obj.Key)
+	}
+	want := "postgres-app-20250102020000.dump.gz,postgres-app-20250102020000.dump.gz.metadata.json," +
+		"mysql-shop-20250101020000.dump.gz,mysql-shop-20250101020000.dump.gz.metadata.json"
+	if got := strings.Join(remaining, ","); got != want {
+		t.Errorf("remaining objects = %s, want %s", got, want)
+	}
+}
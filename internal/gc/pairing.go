@@ -0,0 +1,205 @@
+package gc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/metrics"
+	"label-backup/internal/writer"
+
+	"go.uber.org/zap"
+)
+
+// backupUnit is a backup object together with the metadata sidecar that
+// writer.WriteMetadata names after it. GC keeps or deletes a unit as a whole.
+// When a run was split into several objects, the other objects of the run are
+// in Parts.
+type backupUnit struct {
+	Backup  writer.BackupObjectMeta
+	Sidecar *writer.BackupObjectMeta
+	Parts   []backupUnit
+}
+
+func (u backupUnit) objects() []writer.BackupObjectMeta {
+	objects := []writer.BackupObjectMeta{u.Backup}
+	if u.Sidecar != nil {
+		objects = append(objects, *u.Sidecar)
+	}
+	for _, part := range u.Parts {
+		objects = append(objects, part.objects()...)
+	}
+	return objects
+}
+
+// ownObjects returns the objects that are backups or sidecars of this spec. A
+// prefix is often shared by several containers, whose backups are left to them.
+func (r *Runner) ownObjects(objects []writer.BackupObjectMeta) []writer.BackupObjectMeta {
+	var own []writer.BackupObjectMeta
+	for _, obj := range objects {
+		if writer.IsObjectOf(r.spec, obj.Key) {
+			own = append(own, obj)
+		}
+	}
+	return own
+}
+
+// pairObjects groups backups with their sidecars, and the objects of a split
+// backup run into one unit. Sidecars whose backup is missing are returned
+// separately as orphans.
+func pairObjects(objects []writer.BackupObjectMeta) ([]backupUnit, []writer.BackupObjectMeta) {
+	sidecars := make(map[string]writer.BackupObjectMeta)
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, writer.MetadataSuffix) {
+			sidecars[obj.Key] = obj
+		}
+	}
+
+	var units []backupUnit
+	runs := make(map[string]int)
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, writer.MetadataSuffix) {
+			continue
+		}
+		unit := backupUnit{Backup: obj}
+		if sidecar, ok := sidecars[obj.Key+writer.MetadataSuffix]; ok {
+			unit.Sidecar = &sidecar
+			delete(sidecars, sidecar.Key)
+		}
+		if writer.ObjectPart(obj.Key) != "" {
+			runKey := writer.BackupRunKey(obj.Key)
+			if i, ok := runs[runKey]; ok {
+				units[i].Parts = append(units[i].Parts, unit)
+				continue
+			}
+			runs[runKey] = len(units)
+		}
+		units = append(units, unit)
+	}
+
+	var orphans []writer.BackupObjectMeta
+	for _, obj := range objects {
+		if _, ok := sidecars[obj.Key]; ok {
+			orphans = append(orphans, obj)
+		}
+	}
+	return units, orphans
+}
+
+// deleteObjects deletes the objects in order and stops at the first failure, so a
+// backup whose deletion failed keeps its sidecar. It returns the number of objects
+// deleted and the bytes freed.
+func (r *Runner) deleteObjects(ctx context.Context, objects []writer.BackupObjectMeta, reason string) (int, int64, error) {
+	deleted := 0
+	var freed int64
+	for _, obj := range objects {
+		if r.dryRun {
+			logger.Log.Info("[DryRun] GC: Would delete object",
+				zap.String("containerID", r.spec.ContainerID),
+				zap.String("key", obj.Key),
+				zap.String("reason", reason),
+				zap.Int64("size", obj.Size),
+			)
+			deleted++
+			freed += obj.Size
+			continue
+		}
+
+		deleteCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := r.backupWriter.DeleteObject(deleteCtx, obj.Key)
+		cancel()
+		if err != nil {
+			logger.Log.Error("GC: Failed to delete object",
+				zap.String("containerID", r.spec.ContainerID),
+				zap.String("key", obj.Key),
+				zap.Error(err),
+			)
+			return deleted, freed, fmt.Errorf("failed to delete %s: %w", obj.Key, err)
+		}
+		logger.Log.Info("GC: Successfully deleted object",
+			zap.String("containerID", r.spec.ContainerID),
+			zap.String("key", obj.Key),
+			zap.String("reason", reason),
+			zap.Int64("size", obj.Size),
+		)
+		deleted++
+		freed += obj.Size
+		metrics.GCObjectsDeleted.WithLabelValues(r.spec.ContainerName).Inc()
+		metrics.GCBytesFreed.WithLabelValues(r.spec.ContainerName).Add(float64(obj.Size))
+
+		// Rate limiting - small delay between deletes
+		time.Sleep(100 * time.Millisecond)
+	}
+	return deleted, freed, nil
+}
+
+// SweepOrphans removes metadata sidecars whose backup no longer exists, and backups
+// older than the retention period that never got a sidecar. Only keys belonging to
+// this spec are considered. In dry-run mode it only reports what it would remove.
+func (r *Runner) SweepOrphans(ctx context.Context) error {
+	listCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	objects, err := r.backupWriter.ListObjects(listCtx, r.spec.Prefix)
+	if err != nil {
+		return fmt.Errorf("orphan sweep failed to list objects for prefix '%s': %w", r.spec.Prefix, err)
+	}
+
+	units, orphans := pairObjects(r.ownObjects(objects))
+
+	var targets []writer.BackupObjectMeta
+	for _, orphan := range orphans {
+		logger.Log.Warn("GC: Found orphaned metadata sidecar",
+			zap.String("containerID", r.spec.ContainerID),
+			zap.String("key", orphan.Key),
+		)
+		targets = append(targets, orphan)
+	}
+	if r.effectiveRetention > 0 {
+		cutoffDate := time.Now().UTC().Add(-r.effectiveRetention)
+		for _, run := range units {
+			for _, unit := range append([]backupUnit{run}, run.Parts...) {
+				if unit.Sidecar == nil && unit.Backup.LastModified.Before(cutoffDate) {
+					logger.Log.Warn("GC: Found backup without metadata sidecar past retention",
+						zap.String("containerID", r.spec.ContainerID),
+						zap.String("key", unit.Backup.Key),
+						zap.Time("lastModified", unit.Backup.LastModified),
+					)
+					targets = append(targets, unit.Backup)
+				}
+			}
+		}
+	}
+
+	deleteCount := 0
+	var totalSizeFreed int64
+	var failedDeletes []string
+	for _, obj := range targets {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		deleted, freed, err := r.deleteObjects(ctx, []writer.BackupObjectMeta{obj}, "orphan sweep")
+		deleteCount += deleted
+		totalSizeFreed += freed
+		if err != nil {
+			failedDeletes = append(failedDeletes, obj.Key)
+		}
+	}
+
+	logger.Log.Info("GC orphan sweep completed",
+		zap.String("containerID", r.spec.ContainerID),
+		zap.String("prefix", r.spec.Prefix),
+		zap.Int("orphanedSidecars", len(orphans)),
+		zap.Int("orphansFound", len(targets)),
+		zap.Int("objectsAffected", deleteCount),
+		zap.Int64("totalSizeFreed", totalSizeFreed),
+		zap.Bool("dryRun", r.dryRun),
+		zap.Int("failedDeletes", len(failedDeletes)),
+	)
+
+	if len(failedDeletes) > 0 {
+		return fmt.Errorf("orphan sweep completed with %d failures: %v", len(failedDeletes), failedDeletes)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package gc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+)
+
+func remainingKeys(m *mockBackupWriter) []string {
+	var keys []string
+	for _, obj := range m.objects {
+		keys = append(keys, obj.Key)
+	}
+	return keys
+}
+
+func TestRunGCDeletesSidecarWithBackup(t *testing.T) {
+	now := time.Now().UTC()
+	mock := &mockBackupWriter{objects: []writer.BackupObjectMeta{
+		{Key: "db/postgres-app-20250101020000.dump.gz", LastModified: now.Add(-10 * 24 * time.Hour)},
+		// Rewritten by a restore drill, so newer than the backup itself
+		{Key: "db/postgres-app-20250101020000.dump.gz.metadata.json", LastModified: now.Add(-1 * time.Hour)},
+		{Key: "db/postgres-app-20250110020000.dump.gz", LastModified: now.Add(-1 * 24 * time.Hour)},
+		{Key: "db/postgres-app-20250110020000.dump.gz.metadata.json", LastModified: now.Add(-10 * 24 * time.Hour)},
+	}}
+	spec := model.BackupSpec{ContainerID: "test", Type: "postgres", Database: "app", Prefix: "db"}
+
+	runner, err := NewRunner(spec, mock, 7*24*time.Hour, false, 0, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if err := runner.RunGC(context.Background()); err != nil {
+		t.Fatalf("RunGC() error = %v", err)
+	}
+
+	want := []string{
+		"db/postgres-app-20250110020000.dump.gz",
+		"db/postgres-app-20250110020000.dump.gz.metadata.json",
+	}
+	if got := remainingKeys(mock); !reflect.DeepEqual(got, want) {
+		t.Errorf("remaining objects = %v, want %v", got, want)
+	}
+}
+
+func TestSweepOrphans(t *testing.T) {
+	now := time.Now().UTC()
+	mock := &mockBackupWriter{objects: []writer.BackupObjectMeta{
+		{Key: "db/postgres-app-20250101020000.dump.gz.metadata.json", LastModified: now},
+		{Key: "db/postgres-app-20250102020000.dump.gz", LastModified: now.Add(-10 * 24 * time.Hour)},
+		{Key: "db/postgres-app-20250103020000.dump.gz", LastModified: now.Add(-1 * time.Hour)},
+		{Key: "db/postgres-app-20250104020000.dump.gz", LastModified: now.Add(-10 * 24 * time.Hour)},
+		{Key: "db/postgres-app-20250104020000.dump.gz.metadata.json", LastModified: now.Add(-10 * 24 * time.Hour)},
+		{Key: "db/mysql-shop-20250101020000.dump.gz.metadata.json", LastModified: now},
+	}}
+	spec := model.BackupSpec{ContainerID: "test", Type: "postgres", Database: "app", Prefix: "db"}
+
+	runner, err := NewRunner(spec, mock, 7*24*time.Hour, false, 0, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if err := runner.SweepOrphans(context.Background()); err != nil {
+		t.Fatalf("SweepOrphans() error = %v", err)
+	}
+
+	want := []string{
+		"db/postgres-app-20250103020000.dump.gz",
+		"db/postgres-app-20250104020000.dump.gz",
+		"db/postgres-app-20250104020000.dump.gz.metadata.json",
+		"db/mysql-shop-20250101020000.dump.gz.metadata.json",
+	}
+	if got := remainingKeys(mock); !reflect.DeepEqual(got, want) {
+		t.Errorf("remaining objects = %v, want %v", got, want)
+	}
+}
+
+func TestRunGCKeepsClusterRunTogether(t *testing.T) {
+	old := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	mock := &mockBackupWriter{
+		objects: []writer.BackupObjectMeta{
+			{Key: "db/postgres-cluster-20250101020000.app.dump.gz", LastModified: old},
+			{Key: "db/postgres-cluster-20250101020000.app.dump.gz.metadata.json", LastModified: old},
+			{Key: "db/postgres-cluster-20250101020000.globals.dump.gz", LastModified: old},
+			{Key: "db/postgres-cluster-20250101020000.globals.dump.gz.metadata.json", LastModified: old},
+			{Key: "db/postgres-cluster-20241201020000.app.dump.gz", LastModified: old},
+			{Key: "db/postgres-cluster-20241201020000.app.dump.gz.metadata.json", LastModified: old},
+			{Key: "db/postgres-cluster-20241201020000.globals.dump.gz", LastModified: old},
+			{Key: "db/postgres-cluster-20241201020000.globals.dump.gz.metadata.json", LastModified: old},
+		},
+		contents: map[string]string{
+			"db/postgres-cluster-20250101020000.app.dump.gz.metadata.json":     `{"success": true}`,
+			"db/postgres-cluster-20250101020000.globals.dump.gz.metadata.json": `{"success": true}`,
+			"db/postgres-cluster-20241201020000.app.dump.gz.metadata.json":     `{"success": true}`,
+			"db/postgres-cluster-20241201020000.globals.dump.gz.metadata.json": `{"success": true}`,
+		},
+	}
+	spec := model.BackupSpec{ContainerID: "test", Type: "postgres", Database: "*", Prefix: "db"}
+
+	runner, err := NewRunner(spec, mock, 7*24*time.Hour, false, 1, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if err := runner.RunGC(context.Background()); err != nil {
+		t.Fatalf("RunGC() error = %v", err)
+	}
+
+	// The safeguard keeps every object of the newest successful run
+	want := []string{
+		"db/postgres-cluster-20250101020000.app.dump.gz",
+		"db/postgres-cluster-20250101020000.app.dump.gz.metadata.json",
+		"db/postgres-cluster-20250101020000.globals.dump.gz",
+		"db/postgres-cluster-20250101020000.globals.dump.gz.metadata.json",
+	}
+	if got := remainingKeys(mock); !reflect.DeepEqual(got, want) {
+		t.Errorf("remaining objects = %v, want %v", got, want)
+	}
+}
+
+func TestRunGCSkipsIncompleteRunInSafeguard(t *testing.T) {
+	old := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	incomplete := `{"success": true, "manifest": {"run_id": "r2", "objects": [
+		{"object": "db/mysql-cluster-20250101020000.blog.dump.gz", "part": "blog", "kind": "database", "status": "succeeded"},
+		{"part": "shop", "kind": "database", "status": "failed", "error": "exit status 2"}]}}`
+	mock := &mockBackupWriter{
+		objects: []writer.BackupObjectMeta{
+			{Key: "db/mysql-cluster-20250101020000.blog.dump.gz", LastModified: old},
+			{Key: "db/mysql-cluster-20250101020000.blog.dump.gz.metadata.json", LastModified: old},
+			{Key: "db/mysql-cluster-20241201020000.blog.dump.gz", LastModified: old},
+			{Key: "db/mysql-cluster-20241201020000.blog.dump.gz.metadata.json", LastModified: old},
+			{Key: "db/mysql-cluster-20241201020000.shop.dump.gz", LastModified: old},
+			{Key: "db/mysql-cluster-20241201020000.shop.dump.gz.metadata.json", LastModified: old},
+		},
+		contents: map[string]string{
+			"db/mysql-cluster-20250101020000.blog.dump.gz.metadata.json": incomplete,
+			"db/mysql-cluster-20241201020000.blog.dump.gz.metadata.json": `{"success": true}`,
+			"db/mysql-cluster-20241201020000.shop.dump.gz.metadata.json": `{"success": true}`,
+		},
+	}
+	spec := model.BackupSpec{ContainerID: "test", Type: "mysql", Database: "*", Prefix: "db"}
+
+	runner, err := NewRunner(spec, mock, 7*24*time.Hour, false, 1, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if err := runner.RunGC(context.Background()); err != nil {
+		t.Fatalf("RunGC() error = %v", err)
+	}
+
+	// The newest run lacks a database, so the last complete run is the one kept
+	want := []string{
+		"db/mysql-cluster-20241201020000.blog.dump.gz",
+		"db/mysql-cluster-20241201020000.blog.dump.gz.metadata.json",
+		"db/mysql-cluster-20241201020000.shop.dump.gz",
+		"db/mysql-cluster-20241201020000.shop.dump.gz.metadata.json",
+	}
+	if got := remainingKeys(mock); !reflect.DeepEqual(got, want) {
+		t.Errorf("remaining objects = %v, want %v", got, want)
+	}
+}
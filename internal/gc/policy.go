@@ -0,0 +1,121 @@
+package gc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"label-backup/internal/model"
+)
+
+// RetentionPolicy is a grandfather-father-son policy. A backup is kept if any
+// rule selects it; everything else is released.
+type RetentionPolicy struct {
+	KeepLast    int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	KeepYearly  int
+	// Within keeps every backup younger than the duration, mirroring backup.retention.
+	Within time.Duration
+}
+
+type policyBackup struct {
+	Key       string
+	Timestamp time.Time
+}
+
+type periodRule struct {
+	name   string
+	count  int
+	bucket func(t time.Time) string
+}
+
+func PolicyFromSpec(spec model.BackupSpec) RetentionPolicy {
+	return RetentionPolicy{
+		KeepLast:    spec.RetentionKeepLast,
+		KeepDaily:   spec.RetentionKeepDaily,
+		KeepWeekly:  spec.RetentionKeepWeekly,
+		KeepMonthly: spec.RetentionKeepMonthly,
+		KeepYearly:  spec.RetentionKeepYearly,
+		Within:      spec.Retention,
+	}
+}
+
+// Enabled reports whether any keep-* rule is set. Within alone is plain age-based retention.
+func (p RetentionPolicy) Enabled() bool {
+	return p.KeepLast > 0 || p.KeepDaily > 0 || p.KeepWeekly > 0 || p.KeepMonthly > 0 || p.KeepYearly > 0
+}
+
+func (p RetentionPolicy) String() string {
+	var parts []string
+	add := func(name string, count int) {
+		if count > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", name, count))
+		}
+	}
+	add("keep-last", p.KeepLast)
+	add("keep-daily", p.KeepDaily)
+	add("keep-weekly", p.KeepWeekly)
+	add("keep-monthly", p.KeepMonthly)
+	add("keep-yearly", p.KeepYearly)
+	if p.Within > 0 {
+		parts = append(parts, fmt.Sprintf("within=%s", p.Within))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Evaluate returns, for every backup key, the rules that retain it. Keys with no
+// rules are released. Each period rule keeps the newest backup of its N most
+// recent periods (UTC), so a gap in backups does not extend how far back it reaches.
+func (p RetentionPolicy) Evaluate(backups []policyBackup, now time.Time) map[string][]string {
+	sorted := make([]policyBackup, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.After(sorted[j].Timestamp)
+	})
+
+	kept := make(map[string][]string, len(sorted))
+	for _, b := range sorted {
+		kept[b.Key] = nil
+	}
+
+	for i, b := range sorted {
+		if i < p.KeepLast {
+			kept[b.Key] = append(kept[b.Key], "keep-last")
+		}
+		if p.Within > 0 && b.Timestamp.After(now.Add(-p.Within)) {
+			kept[b.Key] = append(kept[b.Key], "within")
+		}
+	}
+
+	rules := []periodRule{
+		{name: "keep-daily", count: p.KeepDaily, bucket: func(t time.Time) string { return t.Format("2006-01-02") }},
+		{name: "keep-weekly", count: p.KeepWeekly, bucket: func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{name: "keep-monthly", count: p.KeepMonthly, bucket: func(t time.Time) string { return t.Format("2006-01") }},
+		{name: "keep-yearly", count: p.KeepYearly, bucket: func(t time.Time) string { return t.Format("2006") }},
+	}
+	for _, rule := range rules {
+		if rule.count <= 0 {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, b := range sorted {
+			bucket := rule.bucket(b.Timestamp.UTC())
+			if seen[bucket] {
+				continue
+			}
+			if len(seen) >= rule.count {
+				break
+			}
+			seen[bucket] = true
+			kept[b.Key] = append(kept[b.Key], rule.name)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,122 @@
+package gc
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+)
+
+func keptKeys(decisions map[string][]string) []string {
+	var keys []string
+	for key, rules := range decisions {
+		if len(rules) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestRetentionPolicyEvaluate(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	backups := []policyBackup{
+		{Key: "a", Timestamp: time.Date(2025, 3, 15, 2, 0, 0, 0, time.UTC)},
+		{Key: "b", Timestamp: time.Date(2025, 3, 14, 14, 0, 0, 0, time.UTC)},
+		{Key: "c", Timestamp: time.Date(2025, 3, 14, 2, 0, 0, 0, time.UTC)},
+		{Key: "d", Timestamp: time.Date(2025, 3, 1, 2, 0, 0, 0, time.UTC)},
+		{Key: "e", Timestamp: time.Date(2025, 2, 10, 2, 0, 0, 0, time.UTC)},
+		{Key: "f", Timestamp: time.Date(2024, 12, 31, 2, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		want   []string
+	}{
+		{name: "keep-last", policy: RetentionPolicy{KeepLast: 2}, want: []string{"a", "b"}},
+		{name: "keep-daily keeps newest per day", policy: RetentionPolicy{KeepDaily: 2}, want: []string{"a", "b"}},
+		{name: "keep-monthly", policy: RetentionPolicy{KeepMonthly: 3}, want: []string{"a", "e", "f"}},
+		{name: "keep-yearly", policy: RetentionPolicy{KeepYearly: 5}, want: []string{"a", "f"}},
+		{name: "rules combine", policy: RetentionPolicy{KeepLast: 1, KeepMonthly: 2}, want: []string{"a", "e"}},
+		{name: "within", policy: RetentionPolicy{KeepLast: 1, Within: 48 * time.Hour}, want: []string{"a", "b", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := keptKeys(tt.policy.Evaluate(backups, now))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunGCWithPolicy(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		mock := &mockBackupWriter{objects: []writer.BackupObjectMeta{
+			{Key: "db/postgres-app-20250315020000.dump.gz"},
+			{Key: "db/postgres-app-20250315020000.dump.gz.metadata.json"},
+			{Key: "db/postgres-app-20250314020000.dump.gz"},
+			{Key: "db/postgres-app-20250314020000.dump.gz.metadata.json"},
+			{Key: "db/postgres-app-20250313020000.dump.gz.gpg"},
+			{Key: "db/postgres-other-20250101020000.dump.gz"},
+		}}
+		spec := model.BackupSpec{ContainerID: "test", Type: "postgres", Database: "app", Prefix: "db", RetentionKeepLast: 1}
+
+		runner, err := NewRunner(spec, mock, 7*24*time.Hour, dryRun, 0, nil)
+		if err != nil {
+			t.Fatalf("NewRunner() error = %v", err)
+		}
+		if err := runner.RunGC(context.Background()); err != nil {
+			t.Fatalf("RunGC() error = %v", err)
+		}
+
+		var remaining []string
+		for _, obj := range mock.objects {
+			remaining = append(remaining, obj.Key)
+		}
+		want := []string{
+			"db/postgres-app-20250315020000.dump.gz",
+			"db/postgres-app-20250315020000.dump.gz.metadata.json",
+			"db/postgres-other-20250101020000.dump.gz",
+		}
+		if dryRun {
+			want = []string{
+				"db/postgres-app-20250315020000.dump.gz",
+				"db/postgres-app-20250315020000.dump.gz.metadata.json",
+				"db/postgres-app-20250314020000.dump.gz",
+				"db/postgres-app-20250314020000.dump.gz.metadata.json",
+				"db/postgres-app-20250313020000.dump.gz.gpg",
+				"db/postgres-other-20250101020000.dump.gz",
+			}
+		}
+		if !reflect.DeepEqual(remaining, want) {
+			t.Errorf("dryRun=%v: remaining objects = %v, want %v", dryRun, remaining, want)
+		}
+	}
+}
+
+func TestRunGCWithPolicyIgnoresSiblingDatabase(t *testing.T) {
+	mock := &mockBackupWriter{objects: []writer.BackupObjectMeta{
+		{Key: "db/postgres-app-20250315020000.dump.gz"},
+		{Key: "db/postgres-app-staging-20250316020000.dump.gz"},
+		{Key: "db/postgres-app-staging-20250314020000.dump.gz"},
+	}}
+	spec := model.BackupSpec{ContainerID: "test", Type: "postgres", Database: "app", Prefix: "db", RetentionKeepLast: 1}
+
+	runner, err := NewRunner(spec, mock, 7*24*time.Hour, false, 0, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if err := runner.RunGC(context.Background()); err != nil {
+		t.Fatalf("RunGC() error = %v", err)
+	}
+	if len(mock.objects) != 3 {
+		t.Errorf("remaining objects = %v, want the staging backups kept", mock.objects)
+	}
+}
@@ -0,0 +1,112 @@
+package gc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/webhook"
+	"label-backup/internal/writer"
+
+	"go.uber.org/zap"
+)
+
+const DefaultKeepSuccessful = 1
+
+// protectedObjects returns the newest keepSuccessful backups whose metadata reports
+// success. Neither age nor GFS rules may delete them or their sidecars, so a
+// container that has been failing for weeks still has its last good backup. A
+// run split into several objects counts once and is protected as a whole.
+func (r *Runner) protectedObjects(ctx context.Context, objects []writer.BackupObjectMeta) map[string]bool {
+	protected := make(map[string]bool)
+	if r.keepSuccessful <= 0 {
+		return protected
+	}
+
+	var candidates []writer.BackupObjectMeta
+	for _, obj := range objects {
+		if writer.IsObjectOf(r.spec, obj.Key) && !strings.HasSuffix(obj.Key, writer.MetadataSuffix) {
+			candidates = append(candidates, obj)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return backupTime(candidates[i]).After(backupTime(candidates[j]))
+	})
+
+	found := 0
+	counted := make(map[string]bool)
+	for _, obj := range candidates {
+		if found >= r.keepSuccessful || ctx.Err() != nil {
+			break
+		}
+		runKey := writer.BackupRunKey(obj.Key)
+		if counted[runKey] {
+			continue
+		}
+		readCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		metadata, err := writer.ReadMetadata(readCtx, r.backupWriter, obj.Key)
+		cancel()
+		if err != nil {
+			logger.Log.Debug("GC: Could not read backup metadata, not counting it as successful",
+				zap.String("containerID", r.spec.ContainerID),
+				zap.String("key", obj.Key),
+				zap.Error(err),
+			)
+			continue
+		}
+		// A run with failed parts is not a complete backup to fall back on
+		if !metadata.Success || (metadata.Manifest != nil && !metadata.Manifest.Complete()) {
+			continue
+		}
+		counted[runKey] = true
+		for _, c := range candidates {
+			if writer.BackupRunKey(c.Key) == runKey {
+				protected[c.Key] = true
+			}
+		}
+		found++
+	}
+
+	if found < r.keepSuccessful {
+		logger.Log.Warn("GC: Fewer successful backups found than the safeguard keeps",
+			zap.String("containerID", r.spec.ContainerID),
+			zap.Int("found", found),
+			zap.Int("keepSuccessful", r.keepSuccessful),
+		)
+	}
+	return protected
+}
+
+func backupTime(obj writer.BackupObjectMeta) time.Time {
+	if ts, ok := writer.ParseObjectTimestamp(obj.Key); ok {
+		return ts
+	}
+	return obj.LastModified
+}
+
+// notifySafeguard sends a warning webhook when the safeguard stopped a deletion.
+// Dry runs only log, as nothing would have been deleted anyway.
+func (r *Runner) notifySafeguard(keys []string) {
+	if len(keys) == 0 || r.dryRun || r.webhookSender == nil {
+		return
+	}
+
+	payload := webhook.NotificationPayload{
+		Event:           webhook.EventGCSafeguard,
+		Timestamp:       time.Now().UTC().Format(time.RFC3339),
+		ContainerID:     r.spec.ContainerID,
+		ContainerName:   r.spec.ContainerName,
+		DatabaseType:    r.spec.Type,
+		DatabaseName:    r.spec.Database,
+		BackupPrefix:    r.spec.Prefix,
+		DestinationType: r.backupWriter.Type(),
+		Success:         true,
+		ObjectName:      keys[0],
+		Warning: fmt.Sprintf("retention would have deleted the newest %d successful backup(s); kept %s",
+			r.keepSuccessful, strings.Join(keys, ", ")),
+	}
+	r.webhookSender.Enqueue(payload, r.spec)
+}
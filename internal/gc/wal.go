@@ -0,0 +1,123 @@
+package gc
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/writer"
+
+	"go.uber.org/zap"
+)
+
+// splitLogs separates archived WAL and binary logs from the backups. Logs have
+// no age or policy of their own; they are deleted together with the backups
+// that need them.
+func splitLogs(objects []writer.BackupObjectMeta) (backups, logs []writer.BackupObjectMeta) {
+	for _, obj := range objects {
+		if writer.IsWALKey(obj.Key) || writer.IsBinlogKey(obj.Key) {
+			logs = append(logs, obj)
+		} else {
+			backups = append(backups, obj)
+		}
+	}
+	return backups, logs
+}
+
+// collectLogs deletes the archived logs no backup left after this run needs.
+func (r *Runner) collectLogs(ctx context.Context, logObjects []writer.BackupObjectMeta, units []backupUnit, removed map[string]bool) (int, int64, []string) {
+	deleteCount, totalSizeFreed, failedDeletes := r.collectWAL(ctx, logObjects, units, removed)
+	deleted, freed, failed := r.collectBinlogs(ctx, logObjects, units, removed)
+	return deleteCount + deleted, totalSizeFreed + freed, append(failedDeletes, failed...)
+}
+
+// collectWAL deletes the WAL segments archived for this spec that come before the
+// oldest base backup left after this run, which no remaining base backup can use.
+// removed holds the backups this run deleted. It returns the number of objects
+// deleted, the bytes freed and the keys that failed to delete.
+func (r *Runner) collectWAL(ctx context.Context, logObjects []writer.BackupObjectMeta, units []backupUnit, removed map[string]bool) (int, int64, []string) {
+	prefix := writer.WALKeyPrefix(r.spec)
+	var segments []writer.BackupObjectMeta
+	for _, obj := range logObjects {
+		if strings.HasPrefix(obj.Key, prefix) && writer.IsWALSegment(writer.LogFileName(obj.Key)) {
+			segments = append(segments, obj)
+		}
+	}
+	if len(segments) == 0 {
+		return 0, 0, nil
+	}
+
+	start, ok := r.oldestPosition(ctx, units, removed, func(metadata *writer.BackupMetadata) string {
+		if metadata.WAL == nil || !writer.IsWALSegment(metadata.WAL.StartSegment) {
+			return ""
+		}
+		return metadata.WAL.StartSegment
+	})
+	if !ok {
+		logger.Log.Info("GC: Keeping all archived WAL, no remaining base backup records where it starts",
+			zap.String("containerID", r.spec.ContainerID),
+			zap.String("archive", prefix),
+			zap.Int("segments", len(segments)),
+		)
+		return 0, 0, nil
+	}
+
+	deleteCount := 0
+	var totalSizeFreed int64
+	var failedDeletes []string
+	for _, obj := range segments {
+		if ctx.Err() != nil {
+			break
+		}
+		// The timeline is left out, like pg_archivecleanup does
+		if writer.LogFileName(obj.Key)[8:] >= start[8:] {
+			continue
+		}
+		deleted, freed, err := r.deleteObjects(ctx, []writer.BackupObjectMeta{obj}, "before the oldest base backup at "+start)
+		deleteCount += deleted
+		totalSizeFreed += freed
+		if err != nil {
+			failedDeletes = append(failedDeletes, obj.Key)
+		}
+	}
+	return deleteCount, totalSizeFreed, failedDeletes
+}
+
+// oldestPosition returns where in its log the oldest remaining backup starts, as
+// reported by position. Backups without metadata, that failed or for which
+// position returns "" cannot be rolled forward and are passed over; when metadata
+// cannot be read, ok is false and all logs are kept.
+func (r *Runner) oldestPosition(ctx context.Context, units []backupUnit, removed map[string]bool, position func(*writer.BackupMetadata) string) (string, bool) {
+	var remaining []backupUnit
+	for _, unit := range units {
+		if writer.IsObjectOf(r.spec, unit.Backup.Key) && !removed[unit.Backup.Key] && unit.Sidecar != nil {
+			remaining = append(remaining, unit)
+		}
+	}
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return backupTime(remaining[i].Backup).Before(backupTime(remaining[j].Backup))
+	})
+
+	for _, unit := range remaining {
+		readCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		metadata, err := writer.ReadMetadata(readCtx, r.backupWriter, unit.Backup.Key)
+		cancel()
+		if err != nil {
+			logger.Log.Warn("GC: Could not read backup metadata, keeping all archived logs",
+				zap.String("containerID", r.spec.ContainerID),
+				zap.String("key", unit.Backup.Key),
+				zap.Error(err),
+			)
+			return "", false
+		}
+		if !metadata.Success {
+			continue
+		}
+		if start := position(metadata); start != "" {
+			return start, true
+		}
+	}
+	return "", false
+}
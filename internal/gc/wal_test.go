@@ -0,0 +1,83 @@
+package gc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+)
+
+func TestRunGCKeepsWALOfRemainingBaseBackups(t *testing.T) {
+	now := time.Now().UTC()
+	old := now.Add(-30 * 24 * time.Hour)
+	recent := "pg/postgres-physical-postgres-" + now.Add(-time.Hour).Format("20060102150405") + ".dump.gz"
+	wal := "pg/wal/postgres-physical-postgres/"
+	mock := &mockBackupWriter{
+		objects: []writer.BackupObjectMeta{
+			{Key: "pg/postgres-physical-postgres-20250101020000.dump.gz", LastModified: old},
+			{Key: "pg/postgres-physical-postgres-20250101020000.dump.gz.metadata.json", LastModified: old},
+			{Key: recent, LastModified: now},
+			{Key: recent + ".metadata.json", LastModified: now},
+			{Key: wal + "000000010000000000000002.gz", LastModified: old},
+			{Key: wal + "000000010000000000000003.gz", LastModified: old},
+			{Key: wal + "000000010000000000000007.gz", LastModified: old},
+			{Key: wal + "000000020000000000000008.gz", LastModified: now},
+			{Key: wal + "00000002.history.gz", LastModified: old},
+		},
+		contents: map[string]string{
+			"pg/postgres-physical-postgres-20250101020000.dump.gz.metadata.json": `{"success": true, "wal": {"start_lsn": "0/2000028", "start_segment": "000000010000000000000002"}}`,
+			recent + ".metadata.json": `{"success": true, "wal": {"start_lsn": "0/7000028", "start_segment": "000000010000000000000007"}}`,
+		},
+	}
+	spec := model.BackupSpec{ContainerID: "test", Type: "postgres-physical", Conn: "postgresql://backup@db:5432/postgres", Prefix: "pg"}
+
+	runner, err := NewRunner(spec, mock, 7*24*time.Hour, false, 1, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if err := runner.RunGC(context.Background()); err != nil {
+		t.Fatalf("RunGC() error = %v", err)
+	}
+
+	// Old WAL goes with the expired base backup, the remaining one keeps its own
+	want := []string{
+		recent,
+		recent + ".metadata.json",
+		wal + "000000010000000000000007.gz",
+		wal + "000000020000000000000008.gz",
+		wal + "00000002.history.gz",
+	}
+	if got := remainingKeys(mock); !reflect.DeepEqual(got, want) {
+		t.Errorf("remaining objects = %v, want %v", got, want)
+	}
+}
+
+func TestRunGCKeepsWALWithoutBaseBackupStart(t *testing.T) {
+	old := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	wal := "pg/wal/postgres-physical-postgres/"
+	mock := &mockBackupWriter{
+		objects: []writer.BackupObjectMeta{
+			{Key: "pg/postgres-physical-postgres-20250101020000.dump.gz", LastModified: old},
+			{Key: "pg/postgres-physical-postgres-20250101020000.dump.gz.metadata.json", LastModified: old},
+			{Key: wal + "000000010000000000000001.gz", LastModified: old},
+		},
+		contents: map[string]string{
+			"pg/postgres-physical-postgres-20250101020000.dump.gz.metadata.json": `{"success": true}`,
+		},
+	}
+	spec := model.BackupSpec{ContainerID: "test", Type: "postgres-physical", Conn: "postgresql://backup@db:5432/postgres", Prefix: "pg"}
+
+	runner, err := NewRunner(spec, mock, 7*24*time.Hour, false, 1, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	if err := runner.RunGC(context.Background()); err != nil {
+		t.Fatalf("RunGC() error = %v", err)
+	}
+	if got := remainingKeys(mock); len(got) != 3 {
+		t.Errorf("remaining objects = %v, want all of them", got)
+	}
+}
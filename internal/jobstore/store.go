@@ -0,0 +1,304 @@
+package jobstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+
+	TriggerSchedule = "schedule"
+	TriggerAPI      = "api"
+
+	// Phase names recorded in Record.Phases
+	PhaseTestConnection = "test_connection"
+	PhaseDump           = "dump"
+	PhaseWrite          = "write"
+	PhaseMetadata       = "metadata"
+
+	FileName          = "jobs.db"
+	DefaultMaxEntries = 10000
+	DefaultListLimit  = 100
+)
+
+var (
+	jobsBucket  = []byte("jobs")
+	indexBucket = []byte("index")
+)
+
+// Record is the history entry of one backup run.
+type Record struct {
+	ID              string             `json:"job_id"`
+	ContainerID     string             `json:"container_id"`
+	ContainerName   string             `json:"container_name"`
+	DatabaseType    string             `json:"database_type"`
+	DatabaseName    string             `json:"database_name,omitempty"`
+	Trigger         string             `json:"trigger"`
+	Status          string             `json:"status"`
+	StartedAt       time.Time          `json:"started_at"`
+	FinishedAt      *time.Time         `json:"finished_at,omitempty"`
+	DurationSeconds float64            `json:"duration_seconds"`
+	Attempts        int                `json:"attempts,omitempty"`
+	Phases          map[string]float64 `json:"phases,omitempty"`
+	ObjectName      string             `json:"object_name,omitempty"`
+	Objects         []string           `json:"objects,omitempty"`
+	Destination     string             `json:"destination,omitempty"`
+	BytesWritten    int64              `json:"bytes_written"`
+	Checksum        string             `json:"checksum,omitempty"`
+	Hooks           []model.HookResult `json:"hooks,omitempty"`
+	Error           string             `json:"error,omitempty"`
+}
+
+// ListFilter selects records for List. Zero values match everything.
+type ListFilter struct {
+	Container string
+	Status    string
+	Trigger   string
+	Limit     int
+}
+
+// Store keeps job history in a single bbolt file. Records are keyed by start time
+// so iteration runs in chronological order; the index bucket maps job IDs to keys.
+type Store struct {
+	db         *bolt.DB
+	maxAge     time.Duration
+	maxEntries int
+}
+
+// Open opens or creates the history file in stateDir. Entries older than maxAge
+// (when > 0) or beyond the newest maxEntries are rotated out as jobs finish.
+func Open(stateDir string, maxAge time.Duration, maxEntries int) (*Store, error) {
+	if err := os.MkdirAll(stateDir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create state dir %s: %w", stateDir, err)
+	}
+	path := filepath.Join(stateDir, FileName)
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job history %s: %w", path, err)
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	s := &Store{db: db, maxAge: maxAge, maxEntries: maxEntries}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(jobsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(indexBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialise job history %s: %w", path, err)
+	}
+
+	interrupted, err := s.failInterrupted()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if interrupted > 0 {
+		logger.Log.Warn("Marked jobs left running by a previous process as failed", zap.Int("count", interrupted))
+	}
+	logger.Log.Info("Job history store opened",
+		zap.String("path", path),
+		zap.Duration("maxAge", maxAge),
+		zap.Int("maxEntries", maxEntries),
+	)
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func recordKey(rec Record) []byte {
+	key := make([]byte, 8, 8+len(rec.ID))
+	binary.BigEndian.PutUint64(key, uint64(rec.StartedAt.UnixNano()))
+	return append(key, rec.ID...)
+}
+
+// Put inserts or updates a record. Finished records trigger rotation.
+func (s *Store) Put(rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job %s: %w", rec.ID, err)
+	}
+	key := recordKey(rec)
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		index := tx.Bucket(indexBucket)
+		jobs := tx.Bucket(jobsBucket)
+		// StartedAt is fixed for a job, but drop a stale key in case it was not
+		if oldKey := index.Get([]byte(rec.ID)); oldKey != nil && string(oldKey) != string(key) {
+			if err := jobs.Delete(oldKey); err != nil {
+				return err
+			}
+		}
+		if err := jobs.Put(key, data); err != nil {
+			return err
+		}
+		if err := index.Put([]byte(rec.ID), key); err != nil {
+			return err
+		}
+		if rec.Status != StatusRunning {
+			return s.pruneTx(tx, time.Now().UTC())
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store job %s: %w", rec.ID, err)
+	}
+	return nil
+}
+
+// Get returns the record with the given job ID.
+func (s *Store) Get(id string) (Record, bool, error) {
+	var rec Record
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		key := tx.Bucket(indexBucket).Get([]byte(id))
+		if key == nil {
+			return nil
+		}
+		data := tx.Bucket(jobsBucket).Get(key)
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &rec)
+	})
+	if err != nil {
+		return Record{}, false, fmt.Errorf("failed to read job %s: %w", id, err)
+	}
+	return rec, found, nil
+}
+
+// List returns matching records, newest first.
+func (s *Store) List(filter ListFilter) ([]Record, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	records := []Record{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(jobsBucket).Cursor()
+		for k, v := c.Last(); k != nil && len(records) < limit; k, v = c.Prev() {
+			var rec Record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				logger.Log.Warn("Skipping unreadable job history entry", zap.Error(err))
+				continue
+			}
+			if filter.Container != "" && filter.Container != rec.ContainerName && filter.Container != rec.ContainerID {
+				continue
+			}
+			if filter.Status != "" && filter.Status != rec.Status {
+				continue
+			}
+			if filter.Trigger != "" && filter.Trigger != rec.Trigger {
+				continue
+			}
+			records = append(records, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job history: %w", err)
+	}
+	return records, nil
+}
+
+// pruneTx deletes the oldest records past maxAge or beyond maxEntries. Running
+// jobs are only removed by the entry limit.
+func (s *Store) pruneTx(tx *bolt.Tx, now time.Time) error {
+	jobs := tx.Bucket(jobsBucket)
+	index := tx.Bucket(indexBucket)
+	// Stats does not see writes made earlier in this transaction, so count directly
+	c := jobs.Cursor()
+	count := 0
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		count++
+	}
+	excess := count - s.maxEntries
+
+	var stale [][]byte
+	var staleIDs [][]byte
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if excess <= 0 {
+			if s.maxAge <= 0 {
+				break
+			}
+			startedAt := time.Unix(0, int64(binary.BigEndian.Uint64(k[:8])))
+			if !startedAt.Before(now.Add(-s.maxAge)) {
+				break
+			}
+			var rec Record
+			if err := json.Unmarshal(v, &rec); err == nil && rec.Status == StatusRunning {
+				continue
+			}
+		}
+		stale = append(stale, append([]byte(nil), k...))
+		staleIDs = append(staleIDs, append([]byte(nil), k[8:]...))
+		excess--
+	}
+
+	for i, k := range stale {
+		if err := jobs.Delete(k); err != nil {
+			return err
+		}
+		if err := index.Delete(staleIDs[i]); err != nil {
+			return err
+		}
+	}
+	if len(stale) > 0 {
+		logger.Log.Debug("Rotated out old job history entries", zap.Int("count", len(stale)))
+	}
+	return nil
+}
+
+// failInterrupted marks records still running from a previous process as failed.
+func (s *Store) failInterrupted() (int, error) {
+	count := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(jobsBucket)
+		updates := make(map[string][]byte)
+		c := jobs.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var rec Record
+			if err := json.Unmarshal(v, &rec); err != nil || rec.Status != StatusRunning {
+				continue
+			}
+			rec.Status = StatusFailed
+			rec.Error = "interrupted: label-backup stopped before the job finished"
+			data, err := json.Marshal(rec)
+			if err != nil {
+				return err
+			}
+			updates[string(k)] = data
+		}
+		for k, data := range updates {
+			if err := jobs.Put([]byte(k), data); err != nil {
+				return err
+			}
+		}
+		count = len(updates)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to recover interrupted jobs: %w", err)
+	}
+	return count, nil
+}
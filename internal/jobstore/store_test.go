@@ -0,0 +1,119 @@
+package jobstore
+
+import (
+	"testing"
+	"time"
+)
+
+func finishedRecord(id, container string, startedAt time.Time, status string) Record {
+	finishedAt := startedAt.Add(time.Minute)
+	return Record{
+		ID:            id,
+		ContainerName: container,
+		Trigger:       TriggerSchedule,
+		Status:        status,
+		StartedAt:     startedAt,
+		FinishedAt:    &finishedAt,
+	}
+}
+
+func TestStorePutGetList(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer store.Close()
+	base := time.Now().UTC().Add(-time.Hour)
+
+	running := Record{ID: "job-1", ContainerName: "app-db", Trigger: TriggerAPI, Status: StatusRunning, StartedAt: base}
+	if err := store.Put(running); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := store.Put(finishedRecord("job-2", "shop-db", base.Add(time.Minute), StatusFailed)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	running.Status = StatusSucceeded
+	running.Checksum = "abc123"
+	if err := store.Put(running); err != nil {
+		t.Fatalf("Put() update error = %v", err)
+	}
+
+	got, ok, err := store.Get("job-1")
+	if err != nil || !ok {
+		t.Fatalf("Get() = %v, %v", ok, err)
+	}
+	if got.Status != StatusSucceeded || got.Checksum != "abc123" {
+		t.Errorf("Get() = %+v, want updated record", got)
+	}
+
+	all, err := store.List(ListFilter{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(all) != 2 || all[0].ID != "job-2" || all[1].ID != "job-1" {
+		t.Errorf("List() = %+v, want job-2 then job-1", all)
+	}
+	failed, _ := store.List(ListFilter{Status: StatusFailed})
+	if len(failed) != 1 || failed[0].ID != "job-2" {
+		t.Errorf("List(status=failed) = %+v", failed)
+	}
+	byContainer, _ := store.List(ListFilter{Container: "app-db", Trigger: TriggerAPI})
+	if len(byContainer) != 1 || byContainer[0].ID != "job-1" {
+		t.Errorf("List(container=app-db, trigger=api) = %+v", byContainer)
+	}
+}
+
+func TestStoreRotation(t *testing.T) {
+	store, err := Open(t.TempDir(), 24*time.Hour, 3)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer store.Close()
+	now := time.Now().UTC()
+
+	store.Put(finishedRecord("too-old", "app-db", now.Add(-48*time.Hour), StatusSucceeded))
+	for i, id := range []string{"a", "b", "c", "d"} {
+		if err := store.Put(finishedRecord(id, "app-db", now.Add(time.Duration(i-10)*time.Minute), StatusSucceeded)); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	records, err := store.List(ListFilter{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var ids []string
+	for _, rec := range records {
+		ids = append(ids, rec.ID)
+	}
+	if len(ids) != 3 || ids[0] != "d" || ids[2] != "b" {
+		t.Errorf("remaining jobs = %v, want [d c b]", ids)
+	}
+	if _, ok, _ := store.Get("too-old"); ok {
+		t.Error("Get() still finds a rotated-out job")
+	}
+}
+
+func TestOpenFailsInterruptedJobs(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	store.Put(Record{ID: "job-1", Status: StatusRunning, StartedAt: time.Now().UTC()})
+	store.Close()
+
+	store, err = Open(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer store.Close()
+	got, ok, err := store.Get("job-1")
+	if err != nil || !ok {
+		t.Fatalf("Get() = %v, %v", ok, err)
+	}
+	if got.Status != StatusFailed || got.Error == "" {
+		t.Errorf("interrupted job = %+v, want failed with error", got)
+	}
+}
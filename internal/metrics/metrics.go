@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "label_backup"
+
+var (
+	BackupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backups_total",
+		Help:      "Scheduled and on-demand backups by container, database type and status (success, failure or skipped).",
+	}, []string{"container", "db_type", "status"})
+
+	BackupLastSuccessTimestamp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "backup_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful backup per container.",
+	}, []string{"container", "db_type"})
+
+	BackupDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "backup_duration_seconds",
+		Help:      "Duration of backup jobs, from dump start to metadata write.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	}, []string{"container", "db_type"})
+
+	BackupBytesWritten = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backup_bytes_written_total",
+		Help:      "Bytes written to the destination by successful backups.",
+	}, []string{"container", "db_type"})
+
+	GCObjectsDeleted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gc_objects_deleted_total",
+		Help:      "Objects deleted by garbage collection, including metadata sidecars.",
+	}, []string{"container"})
+
+	GCBytesFreed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gc_bytes_freed_total",
+		Help:      "Bytes freed by garbage collection.",
+	}, []string{"container"})
+
+	WebhookAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_attempts_total",
+		Help:      "Webhook HTTP send attempts, including retries, by target host.",
+	}, []string{"host"})
+
+	WebhookFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_failures_total",
+		Help:      "Failed webhook HTTP send attempts by target host.",
+	}, []string{"host"})
+
+	WebhookCircuitBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "webhook_circuit_breaker_state",
+		Help:      "Webhook circuit breaker state: 0 closed, 1 open, 2 half-open.",
+	})
+
+	DiscoveredContainers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "discovered_containers",
+		Help:      "Containers currently in the discovery registry with backups enabled.",
+	})
+)
@@ -0,0 +1,13 @@
+package model
+
+// HookResult is the outcome of a backup.pre-exec or backup.post-exec command run
+// inside the labeled container.
+type HookResult struct {
+	Hook            string  `json:"hook"`
+	Command         string  `json:"command"`
+	ExitCode        int     `json:"exit_code"`
+	Stdout          string  `json:"stdout,omitempty"`
+	Stderr          string  `json:"stderr,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
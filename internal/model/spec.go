@@ -1,17 +1,77 @@
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type BackupSpec struct {
-	Enabled       bool   `json:"enabled"`
-	Type          string `json:"type"`
-	Conn          string `json:"conn"`
-	Database      string `json:"database"`
-	Cron          string `json:"cron"`
-	Dest          string `json:"dest"`
-	Prefix        string `json:"prefix"`
-	Webhook       string `json:"webhook"`
-	Retention     time.Duration `json:"retention"`
-	ContainerID   string `json:"container_id"`
-	ContainerName string `json:"container_name"`
-} 
\ No newline at end of file
+	Enabled              bool          `json:"enabled"`
+	Type                 string        `json:"type"`
+	Conn                 string        `json:"conn"`
+	Database             string        `json:"database"`
+	Command              string        `json:"command,omitempty"`
+	TestCommand          string        `json:"test_command,omitempty"`
+	Cron                 string        `json:"cron"`
+	Dest                 string        `json:"dest"`
+	Mode                 string        `json:"mode,omitempty"`
+	SidecarImage         string        `json:"sidecar_image,omitempty"`
+	PgFormat             string        `json:"pg_format,omitempty"`
+	PgJobs               int           `json:"pg_jobs,omitempty"`
+	PgSlot               string        `json:"pg_slot,omitempty"`
+	IncludeTables        []string      `json:"include_tables,omitempty"`
+	ExcludeTables        []string      `json:"exclude_tables,omitempty"`
+	ExcludeTableData     []string      `json:"exclude_table_data,omitempty"`
+	Schemas              []string      `json:"schemas,omitempty"`
+	MySQLAllDatabases    bool          `json:"mysql_all_databases,omitempty"`
+	MySQLBinlog          bool          `json:"mysql_binlog,omitempty"`
+	Prefix               string        `json:"prefix"`
+	Webhook              string        `json:"webhook"`
+	Retention            time.Duration `json:"retention"`
+	RetentionKeepLast    int           `json:"retention_keep_last,omitempty"`
+	RetentionKeepDaily   int           `json:"retention_keep_daily,omitempty"`
+	RetentionKeepWeekly  int           `json:"retention_keep_weekly,omitempty"`
+	RetentionKeepMonthly int           `json:"retention_keep_monthly,omitempty"`
+	RetentionKeepYearly  int           `json:"retention_keep_yearly,omitempty"`
+	Encrypt              bool          `json:"encrypt"`
+	EncryptionType       string        `json:"encryption_type,omitempty"`
+	EncryptRecipients    []string      `json:"encrypt_recipients,omitempty"`
+	VerifyCron           string        `json:"verify_cron,omitempty"`
+	VerifyQuery          string        `json:"verify_query,omitempty"`
+	VerifyImage          string        `json:"verify_image,omitempty"`
+	RetryMax             *int          `json:"retry_max,omitempty"`
+	RetryBackoff         time.Duration `json:"retry_backoff,omitempty"`
+	RetryMaxBackoff      time.Duration `json:"retry_max_backoff,omitempty"`
+	Priority             int           `json:"priority,omitempty"`
+	PreExec              string        `json:"pre_exec,omitempty"`
+	PreExecTimeout       time.Duration `json:"pre_exec_timeout,omitempty"`
+	PreExecHold          bool          `json:"pre_exec_hold,omitempty"`
+	PostExec             string        `json:"post_exec,omitempty"`
+	PostExecTimeout      time.Duration `json:"post_exec_timeout,omitempty"`
+	ContainerID          string        `json:"container_id"`
+	ContainerName        string        `json:"container_name"`
+}
+
+// AllDatabases is the backup.database value that selects every database of a server.
+const AllDatabases = "*"
+
+// MultiDatabase reports whether backup.database selects several databases, either
+// all of them or a comma-separated list. Each database is then dumped on its own.
+func (s BackupSpec) MultiDatabase() bool {
+	return s.Database == AllDatabases || strings.Contains(s.Database, ",")
+}
+
+// DatabaseList returns the databases of a comma-separated backup.database, or nil
+// when it selects every database or a single one.
+func (s BackupSpec) DatabaseList() []string {
+	if s.Database == AllDatabases || !strings.Contains(s.Database, ",") {
+		return nil
+	}
+	var databases []string
+	for _, db := range strings.Split(s.Database, ",") {
+		if db = strings.TrimSpace(db); db != "" {
+			databases = append(databases, db)
+		}
+	}
+	return databases
+}
@@ -0,0 +1,52 @@
+package restorer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"go.uber.org/zap"
+)
+
+type MongoDBRestorer struct {
+	spec model.BackupSpec
+}
+
+func init() {
+	RegisterRestorerFactory(dumper.MongoDBDumperType, NewMongoDBRestorer)
+}
+
+func NewMongoDBRestorer(spec model.BackupSpec) (Restorer, error) {
+	if spec.Type != dumper.MongoDBDumperType {
+		return nil, fmt.Errorf("invalid restorer type for mongodb: %s", spec.Type)
+	}
+	return &MongoDBRestorer{spec: spec}, nil
+}
+
+// Restore replays the mongodump archive with mongorestore, dropping collections first.
+func (r *MongoDBRestorer) Restore(ctx context.Context, spec model.BackupSpec, reader io.Reader) error {
+	if spec.Conn == "" {
+		return fmt.Errorf("mongodb target connection string is empty")
+	}
+
+	args := []string{"--uri=" + spec.Conn, "--archive", "--drop"}
+	loggedArgs := []string{"--uri=" + dumper.MaskMongoURI(spec.Conn), "--archive", "--drop"}
+
+	cmd := exec.CommandContext(ctx, "mongorestore", args...)
+
+	logger.Log.Info("Executing mongorestore",
+		zap.String("command", "mongorestore"),
+		zap.Strings("args", loggedArgs),
+	)
+
+	return StreamToCommand(ctx, cmd, reader)
+}
+
+func (r *MongoDBRestorer) TestConnection(ctx context.Context, spec model.BackupSpec) error {
+	return testWithDumper(ctx, spec)
+}
@@ -0,0 +1,87 @@
+package restorer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"go.uber.org/zap"
+)
+
+type MySQLRestorer struct {
+	spec model.BackupSpec
+}
+
+func init() {
+	RegisterRestorerFactory(dumper.MySQLDumperType, NewMySQLRestorer)
+}
+
+func NewMySQLRestorer(spec model.BackupSpec) (Restorer, error) {
+	if spec.Type != dumper.MySQLDumperType {
+		return nil, fmt.Errorf("invalid restorer type for mysql: %s", spec.Type)
+	}
+	return &MySQLRestorer{spec: spec}, nil
+}
+
+// Restore pipes the mariadb-dump SQL into the mysql client.
+func (r *MySQLRestorer) Restore(ctx context.Context, spec model.BackupSpec, reader io.Reader) error {
+	cmd, err := mysqlClientCommand(ctx, spec)
+	if err != nil {
+		return err
+	}
+	return StreamToCommand(ctx, cmd, reader)
+}
+
+// mysqlClientCommand returns the mysql client that reads SQL into the target of spec.
+func mysqlClientCommand(ctx context.Context, spec model.BackupSpec) (*exec.Cmd, error) {
+	params, err := dumper.ParseMySQLURI(spec.Conn)
+	if err != nil {
+		return nil, err
+	}
+
+	dbName := params.DBName
+	if spec.Database != "" {
+		dbName = spec.Database
+	}
+
+	args := []string{}
+	if params.Host != "" {
+		args = append(args, "--host="+params.Host)
+	}
+	if params.Port != "" {
+		args = append(args, "--port="+params.Port)
+	}
+	if params.User != "" {
+		args = append(args, "--user="+params.User)
+	}
+	if strings.ToLower(params.SSLMode) == "disable" || strings.ToLower(params.SSLMode) == "disabled" {
+		args = append(args, "--ssl=0")
+	}
+	// Without a database the dump has to select its own, as --all-databases dumps do
+	if dbName != "" {
+		args = append(args, dbName)
+	}
+
+	cmd := exec.CommandContext(ctx, "mysql", args...)
+	if params.Password != "" {
+		cmd.Env = append(os.Environ(), "MYSQL_PWD="+params.Password)
+	}
+
+	logger.Log.Info("Executing mysql restore",
+		zap.String("command", "mysql"),
+		zap.Strings("args", args),
+		zap.String("targetDatabase", dbName),
+	)
+	return cmd, nil
+}
+
+func (r *MySQLRestorer) TestConnection(ctx context.Context, spec model.BackupSpec) error {
+	return testWithDumper(ctx, spec)
+}
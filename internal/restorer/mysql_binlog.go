@@ -0,0 +1,204 @@
+package restorer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/encryption"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+
+	"go.uber.org/zap"
+)
+
+// replayBinlogs rolls a database restored from a dump forward to until, replaying
+// the archived binary logs from the position recorded in the dump's metadata. It
+// returns the binary logs it replayed.
+func replayBinlogs(ctx context.Context, backupWriter writer.BackupWriter, globalConfig map[string]string, spec model.BackupSpec, position *writer.BinlogPosition, until time.Time) ([]string, error) {
+	objects, err := backupWriter.ListObjects(ctx, position.Archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived binary logs in %s: %w", position.Archive, err)
+	}
+	selected, err := binlogObjects(objects, position)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "label-backup-binlog-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var names, files []string
+	for _, obj := range selected {
+		name := writer.LogFileName(obj.Key)
+		path := filepath.Join(dir, name)
+		if err := fetchBinlog(ctx, backupWriter, globalConfig, obj.Key, path); err != nil {
+			return nil, fmt.Errorf("failed to fetch binary log %s: %w", obj.Key, err)
+		}
+		names = append(names, name)
+		files = append(files, path)
+	}
+
+	args := []string{
+		"--start-position=" + strconv.FormatInt(position.Position, 10),
+		"--stop-datetime=" + until.UTC().Format("2006-01-02 15:04:05"),
+	}
+	if position.Database != "" {
+		// Events of other databases on the source server are left out
+		target := targetDatabase(spec)
+		if target != "" && target != position.Database {
+			args = append(args, "--rewrite-db="+position.Database+"->"+target, "--database="+target)
+		} else {
+			args = append(args, "--database="+position.Database)
+		}
+	}
+	args = append(args, files...)
+
+	tool := dumper.MySQLBinlogTool()
+	binlogCmd := exec.CommandContext(ctx, tool, args...)
+	// --stop-datetime is read in the local time zone
+	binlogCmd.Env = append(os.Environ(), "TZ=UTC")
+	var stderrBuf bytes.Buffer
+	binlogCmd.Stderr = &stderrBuf
+	events, err := binlogCmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	mysqlCmd, err := mysqlClientCommand(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Log.Info("Replaying binary logs",
+		zap.String("command", tool),
+		zap.Strings("binlogs", names),
+		zap.Int64("startPosition", position.Position),
+		zap.Time("until", until),
+	)
+	if err := binlogCmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", tool, err)
+	}
+	replayErr := StreamToCommand(ctx, mysqlCmd, events)
+	// Unblocks mysqlbinlog if the client stopped reading early
+	events.Close()
+	waitErr := binlogCmd.Wait()
+	if replayErr != nil {
+		return nil, fmt.Errorf("binary log replay failed: %w", replayErr)
+	}
+	if waitErr != nil {
+		return nil, fmt.Errorf("%s failed (stderr: %s): %w", tool, strings.TrimSpace(stderrBuf.String()), waitErr)
+	}
+	return names, nil
+}
+
+// binlogObjects picks the archived binary logs to replay after a dump: the file
+// the dump's position is in and every later one, without gaps.
+func binlogObjects(objects []writer.BackupObjectMeta, position *writer.BinlogPosition) ([]writer.BackupObjectMeta, error) {
+	base, first, ok := writer.SplitBinlogName(position.File)
+	if !ok {
+		return nil, fmt.Errorf("invalid binary log file name %q in backup metadata", position.File)
+	}
+
+	bySeq := make(map[int]writer.BackupObjectMeta)
+	for _, obj := range objects {
+		objBase, seq, ok := writer.SplitBinlogName(writer.LogFileName(obj.Key))
+		if !ok || objBase != base || seq < first {
+			continue
+		}
+		// A file archived both with and without encryption is taken in its latest form
+		if existing, found := bySeq[seq]; !found || obj.LastModified.After(existing.LastModified) {
+			bySeq[seq] = obj
+		}
+	}
+	if _, found := bySeq[first]; !found {
+		return nil, fmt.Errorf("binary log %s is not in the archive %s", position.File, position.Archive)
+	}
+
+	seqs := make([]int, 0, len(bySeq))
+	for seq := range bySeq {
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	selected := make([]writer.BackupObjectMeta, 0, len(seqs))
+	for i, seq := range seqs {
+		if i > 0 && seq != seqs[i-1]+1 {
+			return nil, fmt.Errorf("binary logs between %s and %s are missing from the archive %s",
+				writer.LogFileName(selected[i-1].Key), writer.LogFileName(bySeq[seq].Key), position.Archive)
+		}
+		selected = append(selected, bySeq[seq])
+	}
+	return selected, nil
+}
+
+// fetchBinlog writes the decrypted, decompressed binary log stored at key to path.
+func fetchBinlog(ctx context.Context, backupWriter writer.BackupWriter, globalConfig map[string]string, key, path string) error {
+	reader, err := backupWriter.ReadObject(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var source io.Reader = reader
+	var decrypted io.ReadCloser
+	if encType := encryption.TypeFromExtension(key); encType != "" {
+		decryptor, err := encryption.GetDecryptor(encType, globalConfig)
+		if err != nil {
+			return fmt.Errorf("encrypted with %s but cannot be decrypted: %w", encType, err)
+		}
+		decrypted, err = decryptor.Decrypt(ctx, reader)
+		if err != nil {
+			return err
+		}
+		source = decrypted
+	}
+
+	err = writeDecompressed(source, path)
+	if decrypted != nil {
+		if closeErr := decrypted.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func writeDecompressed(source io.Reader, path string) error {
+	gz, err := gzip.NewReader(source)
+	if err != nil {
+		return fmt.Errorf("failed to open gzip stream: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, gz)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// targetDatabase returns the database a restore of spec goes into, if one is named.
+func targetDatabase(spec model.BackupSpec) string {
+	if spec.Database != "" {
+		return spec.Database
+	}
+	if params, err := dumper.ParseMySQLURI(spec.Conn); err == nil {
+		return params.DBName
+	}
+	return ""
+}
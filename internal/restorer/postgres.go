@@ -0,0 +1,182 @@
+package restorer
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"go.uber.org/zap"
+)
+
+type PostgresRestorer struct {
+	spec model.BackupSpec
+}
+
+func init() {
+	RegisterRestorerFactory(dumper.PostgresDumperType, NewPostgresRestorer)
+}
+
+func NewPostgresRestorer(spec model.BackupSpec) (Restorer, error) {
+	if spec.Type != dumper.PostgresDumperType {
+		return nil, fmt.Errorf("invalid restorer type for postgres: %s", spec.Type)
+	}
+	return &PostgresRestorer{spec: spec}, nil
+}
+
+// postgresArchiveMagic starts every pg_dump custom-format archive. Other dumps,
+// such as the pg_dumpall globals of a cluster backup, are plain SQL.
+var postgresArchiveMagic = []byte("PGDMP")
+
+// tarMagic sits at tarMagicOffset in the first header of a tar, which is how
+// directory-format dumps are stored.
+var tarMagic = []byte("ustar")
+
+const tarMagicOffset = 257
+
+// Restore feeds the pg_dump -Fc archive to pg_restore, replacing existing objects.
+// Directory-format dumps are unpacked to a scratch directory first, and plain SQL
+// dumps are run through psql instead.
+func (r *PostgresRestorer) Restore(ctx context.Context, spec model.BackupSpec, reader io.Reader) error {
+	params, err := dumper.ParsePostgresURI(spec.Conn)
+	if err != nil {
+		return err
+	}
+
+	dbName := params.DBName
+	if spec.Database != "" {
+		dbName = spec.Database
+	}
+
+	args := []string{}
+	if params.Host != "" {
+		args = append(args, "-h", params.Host)
+	}
+	if params.Port != "" {
+		args = append(args, "-p", params.Port)
+	}
+	if params.User != "" {
+		args = append(args, "-U", params.User)
+	}
+
+	buffered := bufio.NewReader(reader)
+	header, _ := buffered.Peek(tarMagicOffset + len(tarMagic))
+	if len(header) == tarMagicOffset+len(tarMagic) && bytes.Equal(header[tarMagicOffset:], tarMagic) {
+		return restoreDirectory(ctx, spec, params, append(args, "-d", dbName), buffered)
+	}
+
+	command := "pg_restore"
+	if bytes.HasPrefix(header, postgresArchiveMagic) {
+		args = append(args, "-d", dbName, "--clean", "--if-exists", "--no-owner", "--exit-on-error")
+	} else {
+		// Globals fail on roles that already exist, which must not stop the rest
+		command = "psql"
+		args = append(args, "-d", dbName, "-q")
+	}
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	if params.Password != "" {
+		cmd.Env = append(os.Environ(), "PGPASSWORD="+params.Password)
+	}
+
+	logger.Log.Info("Executing "+command,
+		zap.String("command", command),
+		zap.Strings("args", args),
+		zap.String("targetDatabase", dbName),
+		zap.Bool("pgpassword_set", params.Password != ""),
+	)
+
+	return StreamToCommand(ctx, cmd, buffered)
+}
+
+func (r *PostgresRestorer) TestConnection(ctx context.Context, spec model.BackupSpec) error {
+	return testWithDumper(ctx, spec)
+}
+
+// restoreDirectory unpacks the tar of a pg_dump -Fd directory into a scratch
+// directory and runs pg_restore on it, with spec.PgJobs jobs.
+func restoreDirectory(ctx context.Context, spec model.BackupSpec, params *dumper.PostgresConnParams, args []string, reader io.Reader) error {
+	dir, err := os.MkdirTemp("", "label-backup-restore-")
+	if err != nil {
+		return fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := untar(reader, dir); err != nil {
+		return fmt.Errorf("failed to unpack directory-format dump: %w", err)
+	}
+
+	args = append(args, "--clean", "--if-exists", "--no-owner", "--exit-on-error", "-Fd")
+	if spec.PgJobs > 1 {
+		args = append(args, "-j", strconv.Itoa(spec.PgJobs))
+	}
+	args = append(args, filepath.Join(dir, "dump"))
+
+	cmd := exec.CommandContext(ctx, "pg_restore", args...)
+	if params.Password != "" {
+		cmd.Env = append(os.Environ(), "PGPASSWORD="+params.Password)
+	}
+	var stderrBuf bytes.Buffer
+	cmd.Stderr = &stderrBuf
+
+	logger.Log.Info("Executing pg_restore on directory-format dump",
+		zap.String("command", "pg_restore"),
+		zap.Strings("args", args),
+		zap.Bool("pgpassword_set", params.Password != ""),
+	)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restore command failed (stderr: %s): %w", stderrBuf.String(), err)
+	}
+	return nil
+}
+
+// untar extracts regular files and directories from reader below dir, refusing
+// entries that would land outside of it.
+func untar(reader io.Reader, dir string) error {
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("tar entry %s escapes the target directory", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package restorer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"go.uber.org/zap"
+)
+
+type RedisRestorer struct {
+	spec model.BackupSpec
+}
+
+func init() {
+	RegisterRestorerFactory(dumper.RedisDumperType, NewRedisRestorer)
+}
+
+func NewRedisRestorer(spec model.BackupSpec) (Restorer, error) {
+	if spec.Type != dumper.RedisDumperType {
+		return nil, fmt.Errorf("invalid restorer type for redis: %s", spec.Type)
+	}
+	return &RedisRestorer{spec: spec}, nil
+}
+
+// redisTargetPath resolves the RDB file to write. Redis cannot load an RDB over
+// the wire, so the target is the dump file in the server's data directory.
+func redisTargetPath(target string) (string, error) {
+	if strings.HasPrefix(target, "redis://") || strings.HasPrefix(target, "rediss://") {
+		return "", fmt.Errorf("redis restores need a file path to the server's RDB file (e.g. /data/dump.rdb), not a connection URI")
+	}
+	path := strings.TrimPrefix(target, "file://")
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("redis restore target must be an absolute path, got %q", target)
+	}
+	return filepath.Clean(path), nil
+}
+
+// Restore writes the RDB snapshot atomically next to the target and renames it
+// into place. Redis picks it up on its next restart.
+func (r *RedisRestorer) Restore(ctx context.Context, spec model.BackupSpec, reader io.Reader) error {
+	path, err := redisTargetPath(spec.Conn)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".restore-*.rdb")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary RDB file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	written, err := io.Copy(tmp, reader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write RDB file: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to move RDB file into place at %s: %w", path, err)
+	}
+
+	logger.Log.Info("Redis RDB file restored, restart Redis to load it",
+		zap.String("path", path),
+		zap.Int64("bytesWritten", written),
+	)
+	return nil
+}
+
+func (r *RedisRestorer) TestConnection(ctx context.Context, spec model.BackupSpec) error {
+	path, err := redisTargetPath(spec.Conn)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("redis data directory is not accessible: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("redis data directory %s is not a directory", filepath.Dir(path))
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+package restorer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/encryption"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+
+	"go.uber.org/zap"
+)
+
+// Restorer loads an uncompressed, decrypted dump produced by the matching dumper.
+type Restorer interface {
+	Restore(ctx context.Context, spec model.BackupSpec, reader io.Reader) error
+
+	TestConnection(ctx context.Context, spec model.BackupSpec) error
+}
+
+type NewRestorerFunc func(spec model.BackupSpec) (Restorer, error)
+
+var restorerFactories = make(map[string]NewRestorerFunc)
+
+func RegisterRestorerFactory(dbType string, factory NewRestorerFunc) {
+	if factory == nil {
+		logger.Log.Fatal("Restorer factory is nil", zap.String("dbType", dbType))
+	}
+	if _, ok := restorerFactories[dbType]; ok {
+		logger.Log.Fatal("Restorer factory already registered", zap.String("dbType", dbType))
+	}
+	restorerFactories[dbType] = factory
+	logger.Log.Info("Registered restorer factory", zap.String("dbType", dbType))
+}
+
+func GetRestorer(spec model.BackupSpec) (Restorer, error) {
+	factory, ok := restorerFactories[spec.Type]
+	if !ok {
+		err := fmt.Errorf("no restorer registered for database type: %s", spec.Type)
+		logger.Log.Error("Failed to get restorer: no factory registered",
+			zap.String("dbType", spec.Type),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return factory(spec)
+}
+
+// testWithDumper reuses the dumper's connection check, which runs the same client tools.
+func testWithDumper(ctx context.Context, spec model.BackupSpec) error {
+	d, err := dumper.GetDumper(spec)
+	if err != nil {
+		return err
+	}
+	return d.TestConnection(ctx, spec)
+}
+
+// StreamToCommand feeds reader into the command's stdin and waits for it to finish.
+func StreamToCommand(ctx context.Context, cmd *exec.Cmd, reader io.Reader) error {
+	logFields := []zap.Field{
+		zap.String("commandPath", cmd.Path),
+	}
+
+	var stderrBuf bytes.Buffer
+	cmd.Stdin = reader
+	cmd.Stderr = &stderrBuf
+
+	if err := cmd.Start(); err != nil {
+		logger.Log.Error("StreamToCommand: failed to start restore command", append(logFields, zap.Error(err))...)
+		return fmt.Errorf("failed to start restore command: %s: %w", cmd.Path, err)
+	}
+	logger.Log.Info("StreamToCommand: Started command", logFields...)
+
+	if err := cmd.Wait(); err != nil {
+		stderrStr := stderrBuf.String()
+		logger.Log.Error("StreamToCommand: restore command failed",
+			append(logFields,
+				zap.Error(err),
+				zap.String("stderr", stderrStr),
+			)...)
+		return fmt.Errorf("restore command '%s' failed (stderr: %s): %w", cmd.Path, stderrStr, err)
+	}
+
+	if stderrBuf.Len() > 0 {
+		logger.Log.Warn("StreamToCommand: restore command completed with messages on stderr",
+			append(logFields, zap.String("stderr", stderrBuf.String()))...)
+	}
+
+	logger.Log.Info("StreamToCommand: restore command completed", logFields...)
+	return nil
+}
+
+type Request struct {
+	Object   string `json:"object"`
+	Dest     string `json:"dest"`
+	Type     string `json:"type"`
+	Target   string `json:"target"`
+	Database string `json:"database"`
+	// PointInTime, an RFC 3339 time, rolls a MySQL dump forward to that time with
+	// the archived binary logs
+	PointInTime string `json:"point_in_time,omitempty"`
+}
+
+type Result struct {
+	Object          string   `json:"object"`
+	DatabaseType    string   `json:"database_type"`
+	Database        string   `json:"database,omitempty"`
+	Checksum        string   `json:"checksum"`
+	Encrypted       bool     `json:"encrypted"`
+	DurationSeconds float64  `json:"duration_seconds"`
+	ReplayedBinlogs []string `json:"replayed_binlogs,omitempty"`
+}
+
+// RestoreObject verifies a stored backup against its metadata sidecar and streams
+// it into the target connection. The object is read twice: once to verify the
+// checksum before anything touches the target, then again to restore it.
+func RestoreObject(ctx context.Context, req Request, globalConfig map[string]string) (*Result, error) {
+	startTime := time.Now()
+	if req.Object == "" {
+		return nil, fmt.Errorf("object is required")
+	}
+	if req.Target == "" {
+		return nil, fmt.Errorf("target connection string is required")
+	}
+	var until time.Time
+	if req.PointInTime != "" {
+		var err error
+		until, err = time.Parse(time.RFC3339, req.PointInTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid point_in_time %q, expected an RFC 3339 time: %w", req.PointInTime, err)
+		}
+	}
+
+	backupWriter, err := writer.GetWriter(model.BackupSpec{Dest: req.Dest}, globalConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get writer for destination %q: %w", req.Dest, err)
+	}
+
+	metadata, err := writer.ReadMetadata(ctx, backupWriter, req.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata for %s: %w", req.Object, err)
+	}
+	if !metadata.Success {
+		return nil, fmt.Errorf("backup %s is recorded as failed (%s), refusing to restore", req.Object, metadata.Error)
+	}
+	if metadata.Checksum == "" {
+		return nil, fmt.Errorf("metadata for %s has no checksum, cannot verify backup", req.Object)
+	}
+
+	dbType := req.Type
+	if dbType == "" {
+		dbType = metadata.DatabaseType
+	}
+	// The database parts of a multi-database backup hold no USE statement, so they go
+	// back into the database they were dumped from unless told otherwise
+	database := req.Database
+	if database == "" && writer.ObjectPart(req.Object) != "" {
+		database = metadata.DatabaseName
+	}
+	spec := model.BackupSpec{
+		Type:     dbType,
+		Conn:     req.Target,
+		Database: database,
+	}
+	if !until.IsZero() {
+		if dbType != dumper.MySQLDumperType {
+			return nil, fmt.Errorf("point-in-time recovery is only supported for mysql backups")
+		}
+		if metadata.Binlog == nil || metadata.Binlog.Archive == "" {
+			return nil, fmt.Errorf("backup %s has no binary log position, point-in-time recovery needs a backup taken with backup.mysql.binlog=true", req.Object)
+		}
+		if !until.After(metadata.Timestamp) {
+			return nil, fmt.Errorf("point_in_time %s is not after the backup was taken at %s", req.PointInTime, metadata.Timestamp.UTC().Format(time.RFC3339))
+		}
+	}
+
+	r, err := GetRestorer(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	encType := ""
+	if metadata.Encrypted {
+		encType = metadata.EncryptionType
+	}
+	if encType == "" {
+		encType = encryption.TypeFromExtension(req.Object)
+	}
+	var decryptor encryption.Decryptor
+	if encType != "" {
+		decryptor, err = encryption.GetDecryptor(encType, globalConfig)
+		if err != nil {
+			return nil, fmt.Errorf("backup %s is encrypted with %s but cannot be decrypted: %w", req.Object, encType, err)
+		}
+	}
+
+	if err := verifyChecksum(ctx, backupWriter, req.Object, metadata.Checksum); err != nil {
+		return nil, err
+	}
+	logger.Log.Info("Backup checksum verified",
+		zap.String("object", req.Object),
+		zap.String("checksum", metadata.Checksum),
+	)
+
+	if err := r.TestConnection(ctx, spec); err != nil {
+		return nil, fmt.Errorf("target connection test failed: %w", err)
+	}
+
+	objectReader, err := backupWriter.ReadObject(ctx, req.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup object %s: %w", req.Object, err)
+	}
+	defer objectReader.Close()
+
+	var source io.Reader = objectReader
+	var decryptedReader io.ReadCloser
+	if decryptor != nil {
+		decryptedReader, err = decryptor.Decrypt(ctx, objectReader)
+		if err != nil {
+			return nil, err
+		}
+		source = decryptedReader
+	}
+
+	gzipReader, err := gzip.NewReader(source)
+	if err != nil {
+		if decryptedReader != nil {
+			_ = decryptedReader.Close()
+		}
+		return nil, fmt.Errorf("failed to open gzip stream for %s: %w", req.Object, err)
+	}
+
+	logger.Log.Info("Starting restore",
+		zap.String("object", req.Object),
+		zap.String("dbType", dbType),
+		zap.String("encryptionType", encType),
+	)
+
+	restoreErr := r.Restore(ctx, spec, gzipReader)
+	if restoreErr == nil {
+		// Reading to EOF makes gzip validate its trailer
+		if _, err := io.Copy(io.Discard, gzipReader); err != nil {
+			restoreErr = fmt.Errorf("corrupt gzip stream: %w", err)
+		}
+	}
+	if decryptedReader != nil {
+		if err := decryptedReader.Close(); err != nil && restoreErr == nil {
+			restoreErr = err
+		}
+	}
+	if restoreErr != nil {
+		logger.Log.Error("Restore failed", zap.String("object", req.Object), zap.Error(restoreErr))
+		return nil, restoreErr
+	}
+
+	var replayed []string
+	if !until.IsZero() {
+		replayed, err = replayBinlogs(ctx, backupWriter, globalConfig, spec, metadata.Binlog, until)
+		if err != nil {
+			logger.Log.Error("Point-in-time recovery failed after the dump was restored", zap.String("object", req.Object), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	result := &Result{
+		Object:          req.Object,
+		DatabaseType:    dbType,
+		Database:        database,
+		Checksum:        metadata.Checksum,
+		Encrypted:       encType != "",
+		DurationSeconds: time.Since(startTime).Seconds(),
+		ReplayedBinlogs: replayed,
+	}
+	logger.Log.Info("Restore completed successfully",
+		zap.String("object", req.Object),
+		zap.String("dbType", dbType),
+		zap.Float64("durationSeconds", result.DurationSeconds),
+	)
+	return result, nil
+}
+
+func verifyChecksum(ctx context.Context, backupWriter writer.BackupWriter, objectName, expected string) error {
+	reader, err := backupWriter.ReadObject(ctx, objectName)
+	if err != nil {
+		return fmt.Errorf("failed to read backup object %s: %w", objectName, err)
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return fmt.Errorf("failed to read backup object %s for checksum: %w", objectName, err)
+	}
+
+	actual := fmt.Sprintf("%x", hash.Sum(nil))
+	if actual != strings.TrimPrefix(expected, "sha256:") {
+		return fmt.Errorf("checksum mismatch for %s: metadata has %s, object is %s", objectName, expected, actual)
+	}
+	return nil
+}
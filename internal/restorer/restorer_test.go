@@ -0,0 +1,227 @@
+package restorer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+)
+
+func writeTestBackup(t *testing.T, backupDir, objectName string, payload []byte, tamper bool) {
+	t.Helper()
+	ctx := context.Background()
+	cfg := map[string]string{writer.GlobalConfigKeyLocalPath: backupDir}
+	backupWriter, err := writer.GetWriter(model.BackupSpec{Dest: "local"}, cfg)
+	if err != nil {
+		t.Fatalf("GetWriter() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write(payload)
+	gw.Close()
+
+	_, _, checksum, err := backupWriter.Write(ctx, objectName, bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if tamper {
+		checksum = strings.Repeat("0", len(checksum))
+	}
+
+	metadata := writer.BackupMetadata{
+		DatabaseType:    "redis",
+		Checksum:        checksum,
+		CompressionType: "gzip",
+		Success:         true,
+	}
+	if err := writer.WriteMetadata(ctx, backupWriter, metadata, objectName); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+}
+
+func TestRestoreObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  bool
+		wantErr string
+	}{
+		{name: "checksum matches", tamper: false},
+		{name: "checksum mismatch", tamper: true, wantErr: "checksum mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupDir := t.TempDir()
+			dataDir := t.TempDir()
+			payload := []byte("REDIS0011 fake rdb contents")
+			objectName := "cache/redis-0-20250101020000.dump.gz"
+			writeTestBackup(t, backupDir, objectName, payload, tt.tamper)
+
+			target := filepath.Join(dataDir, "dump.rdb")
+			req := Request{Object: objectName, Dest: "local", Target: target}
+			cfg := map[string]string{writer.GlobalConfigKeyLocalPath: backupDir}
+
+			result, err := RestoreObject(context.Background(), req, cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RestoreObject() error = %v, want %q", err, tt.wantErr)
+				}
+				if _, statErr := os.Stat(target); !os.IsNotExist(statErr) {
+					t.Errorf("target was written despite failed verification")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RestoreObject() error = %v", err)
+			}
+			if result.DatabaseType != "redis" {
+				t.Errorf("RestoreObject() DatabaseType = %q, want redis", result.DatabaseType)
+			}
+			got, err := os.ReadFile(target)
+			if err != nil {
+				t.Fatalf("failed to read restored file: %v", err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Errorf("restored file = %q, want %q", got, payload)
+			}
+		})
+	}
+}
+
+// recordingRestorer keeps the spec it was asked to restore with
+type recordingRestorer struct{}
+
+var restoredSpec model.BackupSpec
+
+func (recordingRestorer) Restore(ctx context.Context, spec model.BackupSpec, reader io.Reader) error {
+	restoredSpec = spec
+	_, err := io.Copy(io.Discard, reader)
+	return err
+}
+
+func (recordingRestorer) TestConnection(ctx context.Context, spec model.BackupSpec) error {
+	return nil
+}
+
+func init() {
+	RegisterRestorerFactory("recording-test", func(spec model.BackupSpec) (Restorer, error) {
+		return recordingRestorer{}, nil
+	})
+}
+
+func TestRestoreObjectPartDatabase(t *testing.T) {
+	backupDir := t.TempDir()
+	cfg := map[string]string{writer.GlobalConfigKeyLocalPath: backupDir}
+	backupWriter, err := writer.GetWriter(model.BackupSpec{Dest: "local"}, cfg)
+	if err != nil {
+		t.Fatalf("GetWriter() error = %v", err)
+	}
+	ctx := context.Background()
+	objects := map[string]string{
+		"db/mysql-cluster-20250101020000.shop.dump.gz": "shop",
+		"db/mysql-shop-20250101020000.dump.gz":         "shop",
+	}
+	for objectName, database := range objects {
+		writeTestBackup(t, backupDir, objectName, []byte("CREATE TABLE orders (id INT);"), false)
+		metadata, err := writer.ReadMetadata(ctx, backupWriter, objectName)
+		if err != nil {
+			t.Fatalf("ReadMetadata() error = %v", err)
+		}
+		metadata.DatabaseType = "recording-test"
+		metadata.DatabaseName = database
+		if err := writer.WriteMetadata(ctx, backupWriter, *metadata, objectName); err != nil {
+			t.Fatalf("WriteMetadata() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		object   string
+		database string
+		want     string
+	}{
+		// A part has no USE statement, so it goes back into the database it came from
+		{"db/mysql-cluster-20250101020000.shop.dump.gz", "", "shop"},
+		{"db/mysql-cluster-20250101020000.shop.dump.gz", "shop_copy", "shop_copy"},
+		// A single-database backup still goes where the target says
+		{"db/mysql-shop-20250101020000.dump.gz", "", ""},
+	}
+	for _, tt := range tests {
+		restoredSpec = model.BackupSpec{}
+		req := Request{Object: tt.object, Dest: "local", Target: "mysql://root@db:3306/", Database: tt.database}
+		result, err := RestoreObject(ctx, req, cfg)
+		if err != nil {
+			t.Fatalf("RestoreObject(%s) error = %v", tt.object, err)
+		}
+		if restoredSpec.Database != tt.want || result.Database != tt.want {
+			t.Errorf("RestoreObject(%s, %q) restored into %q (result %q), want %q", tt.object, tt.database, restoredSpec.Database, result.Database, tt.want)
+		}
+	}
+}
+
+func TestUntar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "dump/", Typeflag: tar.TypeDir, Mode: 0o700})
+	_ = tw.WriteHeader(&tar.Header{Name: "dump/toc.dat", Typeflag: tar.TypeReg, Mode: 0o600, Size: 3})
+	_, _ = tw.Write([]byte("toc"))
+	_ = tw.Close()
+
+	dir := t.TempDir()
+	if err := untar(&buf, dir); err != nil {
+		t.Fatalf("untar() error = %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "dump", "toc.dat")); err != nil || string(data) != "toc" {
+		t.Errorf("dump/toc.dat = %q, %v", data, err)
+	}
+
+	buf.Reset()
+	tw = tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "../escape", Typeflag: tar.TypeReg, Mode: 0o600})
+	_ = tw.Close()
+	if err := untar(&buf, t.TempDir()); err == nil {
+		t.Error("untar() accepted an entry outside the target directory")
+	}
+}
+
+func TestBinlogObjects(t *testing.T) {
+	prefix := "binlog/mysql-shop/"
+	now := time.Now()
+	objects := []writer.BackupObjectMeta{
+		{Key: prefix + "mysql-bin.000001.gz", LastModified: now},
+		{Key: prefix + "mysql-bin.000002.gz", LastModified: now},
+		{Key: prefix + "mysql-bin.000003.gz", LastModified: now},
+		{Key: prefix + "mysql-bin.000003.gz.age", LastModified: now.Add(time.Minute)},
+		{Key: prefix + "mysql-bin.index.gz", LastModified: now},
+	}
+	position := &writer.BinlogPosition{File: "mysql-bin.000002", Position: 385, Archive: prefix}
+
+	selected, err := binlogObjects(objects, position)
+	if err != nil {
+		t.Fatalf("binlogObjects() error = %v", err)
+	}
+	var keys []string
+	for _, obj := range selected {
+		keys = append(keys, obj.Key)
+	}
+	if want := prefix + "mysql-bin.000002.gz," + prefix + "mysql-bin.000003.gz.age"; strings.Join(keys, ",") != want {
+		t.Errorf("binlogObjects() = %v, want %s", keys, want)
+	}
+
+	if _, err := binlogObjects(append(objects[:1:1], objects[2:]...), position); err == nil {
+		t.Error("binlogObjects() accepted an archive without the starting file")
+	}
+	position.File = "mysql-bin.000001"
+	if _, err := binlogObjects([]writer.BackupObjectMeta{objects[0], objects[2]}, position); err == nil {
+		t.Error("binlogObjects() accepted a gap in the archive")
+	}
+}
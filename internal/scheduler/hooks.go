@@ -0,0 +1,230 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"label-backup/internal/dockerexec"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"go.uber.org/zap"
+)
+
+const (
+	GlobalConfigKeyHookTimeout = "BACKUP_HOOK_TIMEOUT"
+
+	DefaultHookTimeout = 5 * time.Minute
+
+	HookPre  = "pre"
+	HookPost = "post"
+
+	// Hook output kept in job results and webhooks is cut to its last bytes
+	maxHookOutput = 4096
+)
+
+// runHook runs the backup.pre-exec or backup.post-exec command of spec inside the
+// labeled container through the Docker exec API, with sh -c. Every hook gets an
+// exec session of its own, which ends when the command exits; see holdHook for a
+// pre hook that lasts until the dump is done. It returns nil when spec has no
+// command for hook. A non-zero exit code, an exec error or a timeout is reported
+// in the result's Error.
+func (s *Scheduler) runHook(ctx context.Context, containerID string, spec model.BackupSpec, hook string, env []string) *model.HookResult {
+	command, timeout := s.hookCommand(spec, hook)
+	if command == "" {
+		return nil
+	}
+
+	result := &model.HookResult{Hook: hook, Command: command, ExitCode: -1}
+	if s.discoveryWatcher == nil || s.discoveryWatcher.DockerClient() == nil {
+		result.Error = "no Docker client available to run the hook"
+		logger.Log.Error("Cannot run backup hook", zap.String("containerID", containerID), zap.String("hook", hook))
+		return result
+	}
+
+	logger.Log.Info("Running backup hook",
+		zap.String("containerID", containerID),
+		zap.String("hook", hook),
+		zap.String("command", command),
+		zap.Duration("timeout", timeout),
+	)
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	env = append([]string{"LABEL_BACKUP_HOOK=" + hook}, env...)
+	start := time.Now()
+	res, err := dockerexec.Run(hookCtx, s.discoveryWatcher.DockerClient(), containerID, []string{"sh", "-c", command}, env)
+	result.DurationSeconds = time.Since(start).Seconds()
+	switch {
+	case err != nil && hookCtx.Err() != nil:
+		result.Error = fmt.Sprintf("timed out after %s", timeout)
+	case err != nil:
+		result.Error = err.Error()
+	default:
+		result.ExitCode = res.ExitCode
+		result.Stdout = tailOutput(res.Stdout)
+		result.Stderr = tailOutput(res.Stderr)
+		if res.ExitCode != 0 {
+			result.Error = fmt.Sprintf("exited with code %d", res.ExitCode)
+		}
+	}
+
+	if result.Error != "" {
+		logger.Log.Error("Backup hook failed",
+			zap.String("containerID", containerID),
+			zap.String("hook", hook),
+			zap.String("error", result.Error),
+			zap.String("stderr", result.Stderr),
+		)
+	} else {
+		logger.Log.Info("Backup hook finished",
+			zap.String("containerID", containerID),
+			zap.String("hook", hook),
+			zap.Float64("durationSeconds", result.DurationSeconds),
+		)
+	}
+	return result
+}
+
+// hookCommand returns the command of hook and how long it may take.
+func (s *Scheduler) hookCommand(spec model.BackupSpec, hook string) (string, time.Duration) {
+	command, timeout := spec.PreExec, spec.PreExecTimeout
+	if hook == HookPost {
+		command, timeout = spec.PostExec, spec.PostExecTimeout
+	}
+	if timeout <= 0 {
+		timeout = configDuration(s.globalConfig, GlobalConfigKeyHookTimeout, DefaultHookTimeout)
+	}
+	return command, timeout
+}
+
+// heldHook is a pre hook started by holdHook that runs until releaseHook.
+type heldHook struct {
+	session *dockerexec.Session
+	result  *model.HookResult
+	start   time.Time
+}
+
+// holdHook starts the backup.pre-exec command of a spec with backup.pre-exec.hold
+// and keeps its exec session open, so state tied to the session, such as a table
+// lock, lasts through the dump. The command signals that it holds by writing a
+// line to stdout within the hook timeout; releaseHook later closes its stdin.
+// When the hook fails, the session is nil and the result's Error says why.
+func (s *Scheduler) holdHook(ctx context.Context, containerID string, spec model.BackupSpec) *heldHook {
+	command, timeout := s.hookCommand(spec, HookPre)
+	held := &heldHook{
+		result: &model.HookResult{Hook: HookPre, Command: command, ExitCode: -1},
+		start:  time.Now(),
+	}
+	if s.discoveryWatcher == nil || s.discoveryWatcher.DockerClient() == nil {
+		held.result.Error = "no Docker client available to run the hook"
+		logger.Log.Error("Cannot run backup hook", zap.String("containerID", containerID), zap.String("hook", HookPre))
+		return held
+	}
+
+	logger.Log.Info("Starting held backup hook",
+		zap.String("containerID", containerID),
+		zap.String("command", command),
+		zap.Duration("timeout", timeout),
+	)
+	env := []string{"LABEL_BACKUP_HOOK=" + HookPre}
+	session, err := dockerexec.Start(ctx, s.discoveryWatcher.DockerClient(), containerID, []string{"sh", "-c", command}, env)
+	if err != nil {
+		held.result.Error = err.Error()
+		logger.Log.Error("Backup hook failed", zap.String("containerID", containerID), zap.String("hook", HookPre), zap.String("error", held.result.Error))
+		return held
+	}
+	held.session = session
+
+	readyTimer := time.NewTimer(timeout)
+	defer readyTimer.Stop()
+	select {
+	case <-session.Ready():
+		held.result.DurationSeconds = time.Since(held.start).Seconds()
+		logger.Log.Info("Held backup hook is ready",
+			zap.String("containerID", containerID),
+			zap.Float64("durationSeconds", held.result.DurationSeconds),
+		)
+		return held
+	case <-session.Done():
+		held.result.Error = "exited before writing a line to stdout to signal that it holds"
+	case <-readyTimer.C:
+		held.result.Error = fmt.Sprintf("did not write a line to stdout within %s", timeout)
+	case <-ctx.Done():
+		held.result.Error = fmt.Sprintf("backup ended before the hook was ready: %v", ctx.Err())
+	}
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	held.finish(closeCtx)
+	held.session = nil
+	logger.Log.Error("Backup hook failed",
+		zap.String("containerID", containerID),
+		zap.String("hook", HookPre),
+		zap.String("error", held.result.Error),
+		zap.String("stderr", held.result.Stderr),
+	)
+	return held
+}
+
+// releaseHook closes the stdin of a pre hook started by holdHook and waits, with
+// a fresh hook timeout, for it to exit. A hook that exited before the release
+// held nothing for the rest of the dump, which is reported in the result's Error.
+func (s *Scheduler) releaseHook(containerID string, spec model.BackupSpec, held *heldHook) {
+	select {
+	case <-held.session.Done():
+		held.result.Error = "exited before the backup finished"
+	default:
+	}
+
+	_, timeout := s.hookCommand(spec, HookPre)
+	closeCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	held.finish(closeCtx)
+
+	if held.result.Error != "" {
+		logger.Log.Error("Backup hook failed",
+			zap.String("containerID", containerID),
+			zap.String("hook", HookPre),
+			zap.String("error", held.result.Error),
+			zap.String("stderr", held.result.Stderr),
+		)
+	} else {
+		logger.Log.Info("Held backup hook released",
+			zap.String("containerID", containerID),
+			zap.Float64("durationSeconds", held.result.DurationSeconds),
+		)
+	}
+}
+
+// finish closes the session and records its outcome, keeping an Error that is
+// already set.
+func (h *heldHook) finish(ctx context.Context) {
+	res, err := h.session.Close(ctx)
+	h.result.DurationSeconds = time.Since(h.start).Seconds()
+	if err != nil {
+		if h.result.Error == "" {
+			h.result.Error = fmt.Sprintf("did not exit after its stdin was closed: %v", err)
+		}
+		return
+	}
+	h.result.ExitCode = res.ExitCode
+	h.result.Stdout = tailOutput(res.Stdout)
+	h.result.Stderr = tailOutput(res.Stderr)
+	if res.ExitCode != 0 && h.result.Error == "" {
+		h.result.Error = fmt.Sprintf("exited with code %d", res.ExitCode)
+	}
+}
+
+// postHookEnv tells the post hook how the backup went.
+func postHookEnv(success bool) []string {
+	return []string{"LABEL_BACKUP_SUCCESS=" + strconv.FormatBool(success)}
+}
+
+func tailOutput(output string) string {
+	if len(output) <= maxHookOutput {
+		return output
+	}
+	return "..." + output[len(output)-maxHookOutput:]
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+)
+
+func TestRunBackupPreHookFailureAbortsBackup(t *testing.T) {
+	flakyCalls = 0
+	s := &Scheduler{globalConfig: map[string]string{writer.GlobalConfigKeyLocalPath: t.TempDir()}}
+	spec := model.BackupSpec{
+		Type:          "flaky-test",
+		Database:      "app",
+		Dest:          "local",
+		ContainerName: "app-db",
+		PreExec:       "redis-cli BGSAVE",
+		PostExec:      "echo done",
+	}
+
+	// Without a Docker client both hooks fail; the post hook still runs
+	payload := s.runBackup("abc123", spec, "schedule", "job-1")
+	if payload.Success || !strings.Contains(payload.Error, "pre-backup hook failed") {
+		t.Errorf("runBackup() = success %v, error %q, want a pre-hook failure", payload.Success, payload.Error)
+	}
+	if flakyCalls != 0 {
+		t.Errorf("runBackup() ran the dumper %d times after a failed pre hook", flakyCalls)
+	}
+	if len(payload.Hooks) != 2 || payload.Hooks[0].Hook != HookPre || payload.Hooks[1].Hook != HookPost {
+		t.Fatalf("runBackup() hooks = %+v, want pre and post", payload.Hooks)
+	}
+	if payload.Hooks[1].Error == "" || payload.Warning == "" {
+		t.Errorf("runBackup() did not report the failed post hook: %+v", payload)
+	}
+}
+
+func TestRunBackupHeldPreHookFailureAbortsBackup(t *testing.T) {
+	flakyCalls = 0
+	s := &Scheduler{globalConfig: map[string]string{writer.GlobalConfigKeyLocalPath: t.TempDir()}}
+	spec := model.BackupSpec{
+		Type:          "flaky-test",
+		Database:      "app",
+		Dest:          "local",
+		ContainerName: "app-db",
+		PreExec:       "(echo 'FLUSH TABLES WITH READ LOCK; SELECT 1;'; cat) | mysql -n",
+		PreExecHold:   true,
+	}
+
+	payload := s.runBackup("abc123", spec, "schedule", "job-1")
+	if payload.Success || !strings.Contains(payload.Error, "pre-backup hook failed") {
+		t.Errorf("runBackup() = success %v, error %q, want a pre-hook failure", payload.Success, payload.Error)
+	}
+	if flakyCalls != 0 {
+		t.Errorf("runBackup() ran the dumper %d times after a failed held pre hook", flakyCalls)
+	}
+	if len(payload.Hooks) != 1 || payload.Hooks[0].Hook != HookPre || payload.Hooks[0].Error == "" {
+		t.Errorf("runBackup() hooks = %+v, want the failed pre hook", payload.Hooks)
+	}
+}
+
+func TestTailOutput(t *testing.T) {
+	if got := tailOutput("ok\n"); got != "ok\n" {
+		t.Errorf("tailOutput() = %q, want it unchanged", got)
+	}
+	long := strings.Repeat("a", maxHookOutput) + "end"
+	if got := tailOutput(long); len(got) != maxHookOutput+3 || !strings.HasSuffix(got, "end") {
+		t.Errorf("tailOutput() kept %d bytes, want the last %d", len(got), maxHookOutput)
+	}
+}
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"label-backup/internal/jobstore"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+	"label-backup/internal/webhook"
+
+	"go.uber.org/zap"
+)
+
+const (
+	JobStatusRunning   = jobstore.StatusRunning
+	JobStatusSucceeded = jobstore.StatusSucceeded
+	JobStatusFailed    = jobstore.StatusFailed
+)
+
+// Finished on-demand jobs are kept this long so callers can poll for the result.
+const jobRetention = time.Hour
+
+var (
+	ErrConcurrencyLimit = errors.New("concurrency limit reached")
+	ErrJobRunning       = errors.New("an on-demand backup is already running for this container")
+)
+
+// Job is an on-demand backup started through RunNow.
+type Job struct {
+	ID            string                       `json:"job_id"`
+	ContainerID   string                       `json:"container_id"`
+	ContainerName string                       `json:"container_name"`
+	Status        string                       `json:"status"`
+	StartedAt     time.Time                    `json:"started_at"`
+	FinishedAt    *time.Time                   `json:"finished_at,omitempty"`
+	Result        *webhook.NotificationPayload `json:"result,omitempty"`
+	done          chan struct{}
+}
+
+// jobTable holds the on-demand jobs. It outlives a Scheduler replaced on a
+// configuration reload, see KeepJobs.
+type jobTable struct {
+	mu   sync.Mutex
+	byID map[string]*Job
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// KeepJobs makes s serve the on-demand jobs of prev, which it replaces, so job IDs
+// handed out before a configuration reload stay valid. It must be called before s
+// is used.
+func (s *Scheduler) KeepJobs(prev *Scheduler) {
+	s.jobs = prev.jobs
+}
+
+// RunNow starts a backup outside the cron schedule. It takes a concurrency slot
+// like scheduled runs and fails fast with ErrConcurrencyLimit when none is free.
+func (s *Scheduler) RunNow(containerID string, spec model.BackupSpec) (Job, error) {
+	s.jobs.mu.Lock()
+	for _, existing := range s.jobs.byID {
+		if existing.ContainerID == containerID && existing.Status == JobStatusRunning {
+			s.jobs.mu.Unlock()
+			return Job{}, ErrJobRunning
+		}
+	}
+
+	// On-demand runs do not queue; they only start if all their slots are free
+	resources := backupResources(spec)
+	if !s.slots.tryAcquire(resources) {
+		s.jobs.mu.Unlock()
+		return Job{}, ErrConcurrencyLimit
+	}
+
+	s.pruneJobsLocked()
+	job := &Job{
+		ID:            newJobID(),
+		ContainerID:   containerID,
+		ContainerName: spec.ContainerName,
+		Status:        JobStatusRunning,
+		StartedAt:     time.Now().UTC(),
+		done:          make(chan struct{}),
+	}
+	s.jobs.byID[job.ID] = job
+	snapshot := *job
+	s.jobs.mu.Unlock()
+
+	logger.Log.Info("Starting on-demand backup",
+		zap.String("jobID", job.ID),
+		zap.String("containerID", containerID),
+		zap.String("containerName", spec.ContainerName),
+	)
+
+	go func() {
+		defer s.slots.release(resources)
+
+		payload := s.runBackup(containerID, spec, jobstore.TriggerAPI, job.ID)
+
+		s.jobs.mu.Lock()
+		finishedAt := time.Now().UTC()
+		job.FinishedAt = &finishedAt
+		job.Result = &payload
+		job.Status = JobStatusFailed
+		if payload.Success {
+			job.Status = JobStatusSucceeded
+		}
+		s.jobs.mu.Unlock()
+		close(job.done)
+	}()
+
+	return snapshot, nil
+}
+
+// GetJob returns a snapshot of an on-demand job.
+func (s *Scheduler) GetJob(id string) (Job, bool) {
+	s.jobs.mu.Lock()
+	defer s.jobs.mu.Unlock()
+	job, ok := s.jobs.byID[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// WaitJob blocks until the job finishes or ctx is done and returns its latest snapshot.
+func (s *Scheduler) WaitJob(ctx context.Context, id string) (Job, bool) {
+	s.jobs.mu.Lock()
+	job, ok := s.jobs.byID[id]
+	s.jobs.mu.Unlock()
+	if !ok {
+		return Job{}, false
+	}
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+	}
+	return s.GetJob(id)
+}
+
+func (s *Scheduler) pruneJobsLocked() {
+	cutoff := time.Now().UTC().Add(-jobRetention)
+	for id, job := range s.jobs.byID {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(s.jobs.byID, id)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"label-backup/internal/jobstore"
+	"label-backup/internal/model"
+)
+
+func TestRunNowReportsResult(t *testing.T) {
+	s := NewScheduler(map[string]string{"CONCURRENT_BACKUP_LIMIT": "1"}, nil, nil, nil)
+	defer s.Stop()
+
+	spec := model.BackupSpec{Type: "nosuchdb", ContainerName: "app-db"}
+	job, err := s.RunNow("abc123", spec)
+	if err != nil {
+		t.Fatalf("RunNow() error = %v", err)
+	}
+	if job.Status != JobStatusRunning {
+		t.Errorf("RunNow() status = %q, want %q", job.Status, JobStatusRunning)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	done, ok := s.WaitJob(ctx, job.ID)
+	if !ok {
+		t.Fatalf("WaitJob() did not find job %s", job.ID)
+	}
+	if done.Status != JobStatusFailed || done.Result == nil {
+		t.Fatalf("WaitJob() = %+v, want failed job with result", done)
+	}
+	if !strings.Contains(done.Result.Error, "nosuchdb") {
+		t.Errorf("job result error = %q, want it to mention the dumper type", done.Result.Error)
+	}
+
+	// The concurrency slot is released once the job finishes
+	if _, err := s.RunNow("abc123", spec); err != nil {
+		t.Errorf("second RunNow() error = %v", err)
+	}
+}
+
+func TestRunNowRecordsHistory(t *testing.T) {
+	history, err := jobstore.Open(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("jobstore.Open() error = %v", err)
+	}
+	defer history.Close()
+	s := NewScheduler(map[string]string{}, nil, nil, history)
+	defer s.Stop()
+
+	job, err := s.RunNow("abc123", model.BackupSpec{Type: "nosuchdb", ContainerName: "app-db"})
+	if err != nil {
+		t.Fatalf("RunNow() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s.WaitJob(ctx, job.ID)
+
+	record, ok, err := history.Get(job.ID)
+	if err != nil || !ok {
+		t.Fatalf("history.Get() = %v, %v", ok, err)
+	}
+	if record.Status != jobstore.StatusFailed || record.Trigger != jobstore.TriggerAPI || record.FinishedAt == nil {
+		t.Errorf("history record = %+v, want finished failed api job", record)
+	}
+	if !strings.Contains(record.Error, "nosuchdb") {
+		t.Errorf("history record error = %q, want it to mention the dumper type", record.Error)
+	}
+}
+
+func TestKeepJobsAcrossReload(t *testing.T) {
+	prev := NewScheduler(map[string]string{}, nil, nil, nil)
+	job, err := prev.RunNow("abc123", model.BackupSpec{Type: "nosuchdb", ContainerName: "app-db"})
+	if err != nil {
+		t.Fatalf("RunNow() error = %v", err)
+	}
+
+	next := NewScheduler(map[string]string{}, nil, nil, nil)
+	defer next.Stop()
+	next.KeepJobs(prev)
+	prev.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	done, ok := next.WaitJob(ctx, job.ID)
+	if !ok {
+		t.Fatalf("WaitJob() did not find job %s started before the reload", job.ID)
+	}
+	if done.Status != JobStatusFailed {
+		t.Errorf("job status = %q, want %q", done.Status, JobStatusFailed)
+	}
+}
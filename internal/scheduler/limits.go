@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"go.uber.org/zap"
+)
+
+const (
+	GlobalConfigKeyDestLimit  = "CONCURRENT_DEST_LIMIT"
+	GlobalConfigKeyDestLimits = "CONCURRENT_DEST_LIMITS"
+	GlobalConfigKeyHostLimit  = "CONCURRENT_HOST_LIMIT"
+	GlobalConfigKeyHostLimits = "CONCURRENT_HOST_LIMITS"
+
+	destResourcePrefix = "dest:"
+	hostResourcePrefix = "host:"
+)
+
+// resourceLimits caps concurrent backups per destination and per database host,
+// on top of CONCURRENT_BACKUP_LIMIT. A limit of 0 means unlimited.
+type resourceLimits struct {
+	destDefault int
+	dest        map[string]int
+	hostDefault int
+	host        map[string]int
+}
+
+func (l resourceLimits) limitFor(resource string) int {
+	if name, ok := strings.CutPrefix(resource, destResourcePrefix); ok {
+		if limit, ok := l.dest[name]; ok {
+			return limit
+		}
+		return l.destDefault
+	}
+	if name, ok := strings.CutPrefix(resource, hostResourcePrefix); ok {
+		if limit, ok := l.host[name]; ok {
+			return limit
+		}
+		return l.hostDefault
+	}
+	return 0
+}
+
+func loadResourceLimits(globalConfig map[string]string) resourceLimits {
+	return resourceLimits{
+		destDefault: configLimit(globalConfig, GlobalConfigKeyDestLimit),
+		dest:        configLimitList(globalConfig, GlobalConfigKeyDestLimits),
+		hostDefault: configLimit(globalConfig, GlobalConfigKeyHostLimit),
+		host:        configLimitList(globalConfig, GlobalConfigKeyHostLimits),
+	}
+}
+
+func configLimit(globalConfig map[string]string, key string) int {
+	value := globalConfig[key]
+	if value == "" {
+		return 0
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		logger.Log.Warn("Invalid concurrency limit, not limiting",
+			zap.String("key", key),
+			zap.String("value", value),
+		)
+		return 0
+	}
+	return limit
+}
+
+func configLimitList(globalConfig map[string]string, key string) map[string]int {
+	limits, err := ParseLimitList(globalConfig[key])
+	if err != nil {
+		logger.Log.Warn("Invalid concurrency limit list, ignoring it",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return nil
+	}
+	return limits
+}
+
+// ParseLimitList parses per-key limits such as "remote=2,local=4". Keys are
+// lower-cased; a limit of 0 lifts the default limit for that key.
+func ParseLimitList(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, limitStr, ok := strings.Cut(item, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid entry '%s': expected name=limit", item)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid limit in '%s': must be a non-negative integer", item)
+		}
+		limits[name] = limit
+	}
+	return limits, nil
+}
+
+func destResource(spec model.BackupSpec) string {
+	dest := strings.ToLower(spec.Dest)
+	if dest == "" {
+		dest = "local"
+	}
+	return destResourcePrefix + dest
+}
+
+// backupResources lists the limited resources a backup of spec occupies: its
+// destination and, when it can be parsed from backup.conn, its database host.
+func backupResources(spec model.BackupSpec) []string {
+	resources := []string{destResource(spec)}
+	if host := dumper.ConnHost(spec); host != "" {
+		resources = append(resources, hostResourcePrefix+host)
+	}
+	return resources
+}
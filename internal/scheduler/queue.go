@@ -0,0 +1,268 @@
+package scheduler
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	GlobalConfigKeyQueueMaxDepth = "BACKUP_QUEUE_MAX_DEPTH"
+	GlobalConfigKeyQueueMaxWait  = "BACKUP_QUEUE_MAX_WAIT"
+
+	DefaultQueueMaxDepth = 100
+	DefaultQueueMaxWait  = time.Hour
+)
+
+var (
+	ErrQueueFull    = errors.New("backup queue is full")
+	ErrQueueExpired = errors.New("backup waited too long for a free concurrency slot")
+	errQueueClosed  = errors.New("scheduler stopped while the backup was queued")
+)
+
+type queueEntry struct {
+	containerID   string
+	containerName string
+	priority      int
+	resources     []string
+	enqueuedAt    time.Time
+	seq           uint64
+	ready         chan struct{}
+}
+
+// slotQueue hands out concurrency slots. A caller gets a global slot together with
+// a slot for each of its resources (destination, database host) or nothing, so
+// holding one limit while waiting for another can never deadlock. Callers that
+// cannot start wait in a bounded queue ordered by priority, then by the container
+// served least recently, then by arrival, so one busy container cannot starve
+// others of equal priority. A queued backup whose host is busy does not hold up
+// backups behind it that can run.
+type slotQueue struct {
+	mu         sync.Mutex
+	limit      int
+	running    int
+	limits     resourceLimits
+	inUse      map[string]int
+	maxDepth   int
+	maxWait    time.Duration
+	waiting    []*queueEntry
+	lastServed map[string]time.Time
+	seq        uint64
+	closed     bool
+}
+
+func newSlotQueue(limit, maxDepth int, maxWait time.Duration, limits resourceLimits) *slotQueue {
+	return &slotQueue{
+		limit:      limit,
+		limits:     limits,
+		inUse:      make(map[string]int),
+		maxDepth:   maxDepth,
+		maxWait:    maxWait,
+		lastServed: make(map[string]time.Time),
+	}
+}
+
+func (q *slotQueue) canRunLocked(resources []string) bool {
+	if q.running >= q.limit {
+		return false
+	}
+	for _, resource := range resources {
+		if limit := q.limits.limitFor(resource); limit > 0 && q.inUse[resource] >= limit {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *slotQueue) takeLocked(resources []string) {
+	q.running++
+	for _, resource := range resources {
+		q.inUse[resource]++
+	}
+}
+
+// tryAcquire takes the slots for resources only if they are free right away.
+// Queued callers are dispatched as soon as their slots free up, so this never
+// takes a slot a queued caller could use.
+func (q *slotQueue) tryAcquire(resources []string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || !q.canRunLocked(resources) {
+		return false
+	}
+	q.takeLocked(resources)
+	return true
+}
+
+// acquire takes a slot, waiting up to maxWait in the queue if none is free. It
+// returns how long the caller waited. onQueued is called with the queue depth if
+// the caller has to wait.
+func (q *slotQueue) acquire(containerID, containerName string, priority int, resources []string, onQueued func(depth int)) (time.Duration, error) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return 0, errQueueClosed
+	}
+	if q.canRunLocked(resources) {
+		q.takeLocked(resources)
+		q.lastServed[containerID] = time.Now()
+		q.mu.Unlock()
+		return 0, nil
+	}
+	if len(q.waiting) >= q.maxDepth {
+		q.mu.Unlock()
+		return 0, ErrQueueFull
+	}
+	q.seq++
+	entry := &queueEntry{
+		containerID:   containerID,
+		containerName: containerName,
+		priority:      priority,
+		resources:     resources,
+		enqueuedAt:    time.Now(),
+		seq:           q.seq,
+		ready:         make(chan struct{}),
+	}
+	q.waiting = append(q.waiting, entry)
+	depth := len(q.waiting)
+	q.mu.Unlock()
+
+	if onQueued != nil {
+		onQueued(depth)
+	}
+
+	timer := time.NewTimer(q.maxWait)
+	defer timer.Stop()
+	select {
+	case <-entry.ready:
+	case <-timer.C:
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	waited := time.Since(entry.enqueuedAt)
+	if q.removeLocked(entry) {
+		if q.closed {
+			return waited, errQueueClosed
+		}
+		return waited, ErrQueueExpired
+	}
+	// The entry was handed a slot by release, possibly just as the timer fired
+	return waited, nil
+}
+
+// release returns the slots taken for resources and starts every queued caller
+// that can now run, in queue order.
+func (q *slotQueue) release(resources []string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running--
+	for _, resource := range resources {
+		if q.inUse[resource]--; q.inUse[resource] <= 0 {
+			delete(q.inUse, resource)
+		}
+	}
+	if q.closed {
+		return
+	}
+	for _, entry := range q.orderedLocked() {
+		if !q.canRunLocked(entry.resources) {
+			continue
+		}
+		q.takeLocked(entry.resources)
+		q.removeLocked(entry)
+		q.lastServed[entry.containerID] = time.Now()
+		close(entry.ready)
+	}
+}
+
+// close wakes all queued callers with errQueueClosed. Slots already handed out
+// are still released normally.
+func (q *slotQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	for _, entry := range q.waiting {
+		close(entry.ready)
+	}
+}
+
+func (q *slotQueue) removeLocked(entry *queueEntry) bool {
+	for i, e := range q.waiting {
+		if e == entry {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// orderedLocked returns the queued entries in the order they will be served.
+func (q *slotQueue) orderedLocked() []*queueEntry {
+	ordered := append([]*queueEntry(nil), q.waiting...)
+	sort.Slice(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if a.priority != b.priority {
+			return a.priority > b.priority
+		}
+		servedA, servedB := q.lastServed[a.containerID], q.lastServed[b.containerID]
+		if !servedA.Equal(servedB) {
+			return servedA.Before(servedB)
+		}
+		return a.seq < b.seq
+	})
+	return ordered
+}
+
+// QueuedBackup is a backup waiting for a concurrency slot.
+type QueuedBackup struct {
+	Position       int       `json:"position"`
+	ContainerID    string    `json:"container_id"`
+	ContainerName  string    `json:"container_name"`
+	Priority       int       `json:"priority"`
+	Resources      []string  `json:"resources"`
+	EnqueuedAt     time.Time `json:"enqueued_at"`
+	WaitingSeconds float64   `json:"waiting_seconds"`
+}
+
+type QueueStatus struct {
+	Limit          int            `json:"concurrency_limit"`
+	Running        int            `json:"running"`
+	ResourcesInUse map[string]int `json:"resources_in_use"`
+	MaxDepth       int            `json:"max_depth"`
+	MaxWaitSeconds float64        `json:"max_wait_seconds"`
+	Queued         []QueuedBackup `json:"queued"`
+}
+
+func (q *slotQueue) status() QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	status := QueueStatus{
+		Limit:          q.limit,
+		Running:        q.running,
+		ResourcesInUse: make(map[string]int),
+		MaxDepth:       q.maxDepth,
+		MaxWaitSeconds: q.maxWait.Seconds(),
+		Queued:         []QueuedBackup{},
+	}
+	for resource, count := range q.inUse {
+		status.ResourcesInUse[resource] = count
+	}
+	for i, entry := range q.orderedLocked() {
+		status.Queued = append(status.Queued, QueuedBackup{
+			Position:       i + 1,
+			ContainerID:    entry.containerID,
+			ContainerName:  entry.containerName,
+			Priority:       entry.priority,
+			Resources:      entry.resources,
+			EnqueuedAt:     entry.enqueuedAt.UTC(),
+			WaitingSeconds: now.Sub(entry.enqueuedAt).Seconds(),
+		})
+	}
+	return status
+}
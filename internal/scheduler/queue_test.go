@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitForDepth(t *testing.T, q *slotQueue, depth int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(q.status().Queued) != depth {
+		if time.Now().After(deadline) {
+			t.Fatalf("queue depth = %d, want %d", len(q.status().Queued), depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSlotQueueOrder(t *testing.T) {
+	q := newSlotQueue(1, 10, time.Minute, resourceLimits{})
+	if _, err := q.acquire("busy", "busy", 0, nil, nil); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	// "frequent" ran recently and "fresh" never has, so "fresh" goes first at equal priority
+	q.lastServed["frequent"] = time.Now()
+	order := make(chan string, 3)
+	for i, c := range []struct {
+		id       string
+		priority int
+	}{{"frequent", 0}, {"fresh", 0}, {"urgent", 5}} {
+		go func(id string, priority int) {
+			if _, err := q.acquire(id, id, priority, nil, nil); err != nil {
+				t.Errorf("acquire(%s) error = %v", id, err)
+				return
+			}
+			order <- id
+			q.release(nil)
+		}(c.id, c.priority)
+		waitForDepth(t, q, i+1)
+	}
+
+	if q.tryAcquire(nil) {
+		t.Fatal("tryAcquire() jumped the queue")
+	}
+	q.release(nil)
+
+	want := []string{"urgent", "fresh", "frequent"}
+	for _, id := range want {
+		select {
+		case got := <-order:
+			if got != id {
+				t.Fatalf("served %s, want %s", got, id)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", id)
+		}
+	}
+	if status := q.status(); status.Running != 0 || len(status.Queued) != 0 {
+		t.Errorf("status after drain = %+v", status)
+	}
+}
+
+func TestSlotQueueLimits(t *testing.T) {
+	q := newSlotQueue(1, 1, 20*time.Millisecond, resourceLimits{})
+	if _, err := q.acquire("a", "a", 0, nil, nil); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	expired := make(chan error, 1)
+	go func() {
+		_, err := q.acquire("b", "b", 0, nil, nil)
+		expired <- err
+	}()
+	waitForDepth(t, q, 1)
+
+	if _, err := q.acquire("c", "c", 0, nil, nil); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("acquire() on a full queue error = %v, want ErrQueueFull", err)
+	}
+	if err := <-expired; !errors.Is(err, ErrQueueExpired) {
+		t.Errorf("acquire() past max wait error = %v, want ErrQueueExpired", err)
+	}
+
+	go func() {
+		_, err := q.acquire("d", "d", 0, nil, nil)
+		expired <- err
+	}()
+	waitForDepth(t, q, 1)
+	q.close()
+	if err := <-expired; !errors.Is(err, errQueueClosed) {
+		t.Errorf("acquire() after close error = %v, want errQueueClosed", err)
+	}
+}
+
+func TestSlotQueueResourceLimits(t *testing.T) {
+	q := newSlotQueue(3, 10, time.Minute, resourceLimits{
+		destDefault: 2,
+		hostDefault: 1,
+		host:        map[string]int{"replica": 0},
+	})
+	pg1 := []string{"dest:remote", "host:pg1"}
+	pg2 := []string{"dest:remote", "host:pg2"}
+	if _, err := q.acquire("a", "a", 0, pg1, nil); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	// A second backup of pg1 waits for the host even though global slots are free
+	granted := make(chan string, 2)
+	go func() {
+		if _, err := q.acquire("b", "b", 5, pg1, nil); err != nil {
+			t.Errorf("acquire(b) error = %v", err)
+			return
+		}
+		granted <- "b"
+	}()
+	waitForDepth(t, q, 1)
+
+	// A queued backup blocked on its host does not hold up one for another host
+	if _, err := q.acquire("c", "c", 0, pg2, nil); err != nil {
+		t.Fatalf("acquire(c) error = %v", err)
+	}
+	if q.tryAcquire([]string{"dest:remote", "host:replica"}) {
+		t.Fatal("tryAcquire() ignored the destination limit")
+	}
+	if !q.tryAcquire([]string{"dest:local", "host:replica"}) {
+		t.Fatal("tryAcquire() refused free slots")
+	}
+	q.release([]string{"dest:local", "host:replica"})
+
+	q.release(pg2)
+	select {
+	case id := <-granted:
+		t.Fatalf("%s started while pg1 was busy", id)
+	case <-time.After(20 * time.Millisecond):
+	}
+	q.release(pg1)
+	select {
+	case <-granted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for b")
+	}
+	if status := q.status(); status.Running != 1 || status.ResourcesInUse["host:pg1"] != 1 || len(status.Queued) != 0 {
+		t.Errorf("status = %+v", status)
+	}
+}
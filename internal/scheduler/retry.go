@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"math/rand"
+	"strconv"
+	"time"
+
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+
+	"go.uber.org/zap"
+)
+
+const (
+	GlobalConfigKeyRetryMax        = "BACKUP_RETRY_MAX"
+	GlobalConfigKeyRetryBackoff    = "BACKUP_RETRY_BACKOFF"
+	GlobalConfigKeyRetryMaxBackoff = "BACKUP_RETRY_MAX_BACKOFF"
+
+	DefaultRetryBackoff    = 30 * time.Second
+	DefaultRetryMaxBackoff = 10 * time.Minute
+)
+
+// retryPolicy resolves how often and how long to back off for a spec. Labels
+// override the global defaults; without either a failed backup is not retried.
+func (s *Scheduler) retryPolicy(spec model.BackupSpec) (int, time.Duration, time.Duration) {
+	maxRetries := 0
+	if value := s.globalConfig[GlobalConfigKeyRetryMax]; value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			maxRetries = n
+		} else {
+			logger.Log.Warn("Invalid BACKUP_RETRY_MAX value, not retrying", zap.String("value", value))
+		}
+	}
+	if spec.RetryMax != nil {
+		maxRetries = *spec.RetryMax
+	}
+
+	backoff := configDuration(s.globalConfig, GlobalConfigKeyRetryBackoff, DefaultRetryBackoff)
+	if spec.RetryBackoff > 0 {
+		backoff = spec.RetryBackoff
+	}
+	maxBackoff := configDuration(s.globalConfig, GlobalConfigKeyRetryMaxBackoff, DefaultRetryMaxBackoff)
+	if spec.RetryMaxBackoff > 0 {
+		maxBackoff = spec.RetryMaxBackoff
+	}
+	if maxBackoff < backoff {
+		maxBackoff = backoff
+	}
+	return maxRetries, backoff, maxBackoff
+}
+
+// configDuration reads a Go duration from the global config, falling back to
+// defaultValue when it is unset or invalid.
+func configDuration(globalConfig map[string]string, key string, defaultValue time.Duration) time.Duration {
+	value := globalConfig[key]
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logger.Log.Warn("Invalid duration in global config, using default",
+			zap.String("key", key),
+			zap.String("value", value),
+			zap.Duration("default", defaultValue),
+		)
+		return defaultValue
+	}
+	return d
+}
+
+// backoffDelay returns the wait before retry number attempt (1-based): the base
+// doubled per attempt and capped at maxBackoff, with the upper half jittered so
+// containers that failed together do not retry in lockstep.
+func backoffDelay(attempt int, base, maxBackoff time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"label-backup/internal/dumper"
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+)
+
+type flakyDumper struct {
+	failures int
+	calls    *int
+}
+
+func (d *flakyDumper) TestConnection(ctx context.Context, spec model.BackupSpec) error {
+	*d.calls++
+	if *d.calls <= d.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (d *flakyDumper) Dump(ctx context.Context, spec model.BackupSpec, w io.Writer) error {
+	_, err := w.Write([]byte("dump"))
+	return err
+}
+
+var flakyCalls int
+
+func init() {
+	dumper.RegisterDumperFactory("flaky-test", func(spec model.BackupSpec) (dumper.Dumper, error) {
+		return &flakyDumper{failures: 2, calls: &flakyCalls}, nil
+	})
+}
+
+func TestBackoffDelay(t *testing.T) {
+	base := 10 * time.Second
+	maxBackoff := time.Minute
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{attempt: 1, min: 5 * time.Second, max: 10 * time.Second},
+		{attempt: 2, min: 10 * time.Second, max: 20 * time.Second},
+		{attempt: 3, min: 20 * time.Second, max: 40 * time.Second},
+		{attempt: 10, min: 30 * time.Second, max: time.Minute},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if d := backoffDelay(tt.attempt, base, maxBackoff); d < tt.min || d > tt.max {
+				t.Fatalf("backoffDelay(%d) = %v, want between %v and %v", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	s := &Scheduler{globalConfig: map[string]string{
+		GlobalConfigKeyRetryMax:     "3",
+		GlobalConfigKeyRetryBackoff: "1m",
+	}}
+
+	maxRetries, backoff, maxBackoff := s.retryPolicy(model.BackupSpec{})
+	if maxRetries != 3 || backoff != time.Minute || maxBackoff != DefaultRetryMaxBackoff {
+		t.Errorf("retryPolicy() = (%d, %v, %v), want global defaults", maxRetries, backoff, maxBackoff)
+	}
+
+	disabled := 0
+	maxRetries, backoff, _ = s.retryPolicy(model.BackupSpec{RetryMax: &disabled, RetryBackoff: 5 * time.Second})
+	if maxRetries != 0 || backoff != 5*time.Second {
+		t.Errorf("retryPolicy() with labels = (%d, %v), want (0, 5s)", maxRetries, backoff)
+	}
+}
+
+func TestRunBackupRetriesFailedAttempts(t *testing.T) {
+	flakyCalls = 0
+	s := &Scheduler{globalConfig: map[string]string{writer.GlobalConfigKeyLocalPath: t.TempDir()}}
+	retries := 2
+	spec := model.BackupSpec{
+		Type:            "flaky-test",
+		Database:        "app",
+		Dest:            "local",
+		ContainerName:   "app-db",
+		RetryMax:        &retries,
+		RetryBackoff:    time.Millisecond,
+		RetryMaxBackoff: time.Millisecond,
+	}
+
+	payload := s.runBackup("abc123", spec, "schedule", "job-1")
+	if !payload.Success || payload.Attempts != 3 {
+		t.Errorf("runBackup() = success %v after %d attempts, want success after 3 (error: %s)", payload.Success, payload.Attempts, payload.Error)
+	}
+
+	flakyCalls = 0
+	retries = 1
+	payload = s.runBackup("abc123", spec, "schedule", "job-2")
+	if payload.Success || payload.Attempts != 2 {
+		t.Errorf("runBackup() = success %v after %d attempts, want failure after 2", payload.Success, payload.Attempts)
+	}
+}
@@ -2,17 +2,24 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"label-backup/internal/archiver"
 	"label-backup/internal/discovery"
 	"label-backup/internal/dumper"
+	"label-backup/internal/encryption"
+	"label-backup/internal/jobstore"
 	"label-backup/internal/logger"
+	"label-backup/internal/metrics"
 	"label-backup/internal/model"
+	"label-backup/internal/verifier"
 	"label-backup/internal/webhook"
 	"label-backup/internal/writer"
 
@@ -29,13 +36,18 @@ type Scheduler struct {
 	cron             *cron.Cron
 	mu               sync.Mutex
 	activeJobs       map[string]*scheduledJob
+	verifyJobs       map[string]*scheduledJob
 	globalConfig     map[string]string
 	webhookSender    webhook.WebhookSender
 	discoveryWatcher *discovery.Watcher
-	concurrencyLimit chan struct{}
+	slots            *slotQueue
+	jobs             *jobTable
+	history          *jobstore.Store
+	archives         *archiver.Manager
 }
 
-func NewScheduler(globalCfg map[string]string, whSender webhook.WebhookSender, dw *discovery.Watcher) *Scheduler {
+// history may be nil, in which case backup runs are not recorded.
+func NewScheduler(globalCfg map[string]string, whSender webhook.WebhookSender, dw *discovery.Watcher, history *jobstore.Store) *Scheduler {
 	c := cron.New(
 		cron.WithSeconds(),
 		cron.WithChain(
@@ -57,16 +69,38 @@ func NewScheduler(globalCfg map[string]string, whSender webhook.WebhookSender, d
 		}
 	}
 	
+	queueMaxDepth := DefaultQueueMaxDepth
+	if depthStr := globalCfg[GlobalConfigKeyQueueMaxDepth]; depthStr != "" {
+		if depth, err := strconv.Atoi(depthStr); err == nil && depth >= 0 {
+			queueMaxDepth = depth
+		} else {
+			logger.Log.Warn("Invalid BACKUP_QUEUE_MAX_DEPTH value, using default",
+				zap.String("value", depthStr),
+				zap.Int("default", DefaultQueueMaxDepth),
+			)
+		}
+	}
+
+	queueMaxWait := configDuration(globalCfg, GlobalConfigKeyQueueMaxWait, DefaultQueueMaxWait)
+
 	s := &Scheduler{
 		cron:             c,
 		activeJobs:       make(map[string]*scheduledJob),
+		verifyJobs:       make(map[string]*scheduledJob),
 		globalConfig:     globalCfg,
 		webhookSender:    whSender,
 		discoveryWatcher: dw,
-		concurrencyLimit: make(chan struct{}, concurrencyLimit),
+		slots:            newSlotQueue(concurrencyLimit, queueMaxDepth, queueMaxWait, loadResourceLimits(globalCfg)),
+		jobs:             &jobTable{byID: make(map[string]*Job)},
+		history:          history,
+		archives:         archiver.NewManager(globalCfg),
 	}
 	s.cron.Start()
-	logger.Log.Info("Cron scheduler started", zap.Int("concurrencyLimit", concurrencyLimit))
+	logger.Log.Info("Cron scheduler started",
+		zap.Int("concurrencyLimit", concurrencyLimit),
+		zap.Int("queueMaxDepth", queueMaxDepth),
+		zap.Duration("queueMaxWait", queueMaxWait),
+	)
 	return s
 }
 
@@ -74,17 +108,20 @@ func (s *Scheduler) AddOrUpdateJob(containerID string, spec model.BackupSpec) er
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.syncVerifyJob(containerID, spec)
+	s.archives.Sync(containerID, spec)
+
 	existingJob, exists := s.activeJobs[containerID]
 	if exists {
-		if existingJob.spec.Cron == spec.Cron {
-			logger.Log.Debug("Cron spec unchanged for existing job, updating internal spec details only",
+		if reflect.DeepEqual(existingJob.spec, spec) {
+			logger.Log.Debug("Spec unchanged for existing job",
 				zap.String("containerID", containerID),
 				zap.String("cron", spec.Cron))
-			existingJob.spec = spec
 			return nil
 		}
 
-		logger.Log.Info("Cron spec changed for existing job, re-scheduling",
+		// The cron entry runs with the spec it was added with, so any change re-schedules
+		logger.Log.Info("Spec changed for existing job, re-scheduling",
 			zap.String("containerID", containerID),
 			zap.String("oldCron", existingJob.spec.Cron),
 			zap.String("newCron", spec.Cron))
@@ -93,18 +130,7 @@ func (s *Scheduler) AddOrUpdateJob(containerID string, spec model.BackupSpec) er
 
 	jobFunction := s.jobFunc(containerID, spec)
 
-	cronSpecToUse := spec.Cron
-	trimmedCron := strings.TrimSpace(spec.Cron)
-	fields := strings.Fields(trimmedCron)
-
-	if !strings.HasPrefix(trimmedCron, "@") && len(fields) == 5 {
-		cronSpecToUse = "0 " + trimmedCron
-		logger.Log.Info("Converted 5-field cron expression to 6-field",
-			zap.String("containerID", containerID),
-			zap.String("originalCron", spec.Cron),
-			zap.String("convertedCron", cronSpecToUse),
-		)
-	}
+	cronSpecToUse := toSixFieldCron(containerID, spec.Cron)
 
 	newCronID, err := s.cron.AddFunc(cronSpecToUse, jobFunction)
 	if err != nil {
@@ -134,10 +160,71 @@ func (s *Scheduler) AddOrUpdateJob(containerID string, spec model.BackupSpec) er
 	return nil
 }
 
+// toSixFieldCron prepends a seconds field to standard 5-field expressions,
+// since the scheduler's cron parser runs with seconds enabled.
+func toSixFieldCron(containerID, cronExpr string) string {
+	trimmedCron := strings.TrimSpace(cronExpr)
+	fields := strings.Fields(trimmedCron)
+
+	if !strings.HasPrefix(trimmedCron, "@") && len(fields) == 5 {
+		converted := "0 " + trimmedCron
+		logger.Log.Info("Converted 5-field cron expression to 6-field",
+			zap.String("containerID", containerID),
+			zap.String("originalCron", cronExpr),
+			zap.String("convertedCron", converted),
+		)
+		return converted
+	}
+	return cronExpr
+}
+
+// syncVerifyJob schedules, reschedules or removes the restore drill for a container.
+// The caller must hold s.mu.
+func (s *Scheduler) syncVerifyJob(containerID string, spec model.BackupSpec) {
+	existingJob, exists := s.verifyJobs[containerID]
+	if spec.VerifyCron == "" {
+		if exists {
+			s.cron.Remove(existingJob.cronID)
+			delete(s.verifyJobs, containerID)
+			logger.Log.Info("Removed restore drill job", zap.String("containerID", containerID))
+		}
+		return
+	}
+	if exists {
+		if reflect.DeepEqual(existingJob.spec, spec) {
+			return
+		}
+		s.cron.Remove(existingJob.cronID)
+	}
+
+	cronID, err := s.cron.AddFunc(toSixFieldCron(containerID, spec.VerifyCron), s.verifyJobFunc(containerID, spec))
+	if err != nil {
+		delete(s.verifyJobs, containerID)
+		logger.Log.Error("Failed to add restore drill job",
+			zap.String("containerID", containerID),
+			zap.String("verifyCron", spec.VerifyCron),
+			zap.Error(err),
+		)
+		return
+	}
+	s.verifyJobs[containerID] = &scheduledJob{spec: spec, cronID: cronID}
+	logger.Log.Info("Scheduled restore drill job",
+		zap.String("containerID", containerID),
+		zap.String("verifyCron", spec.VerifyCron),
+		zap.String("image", spec.VerifyImage),
+	)
+}
+
 func (s *Scheduler) RemoveJob(containerID string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if verifyJob, exists := s.verifyJobs[containerID]; exists {
+		s.cron.Remove(verifyJob.cronID)
+		delete(s.verifyJobs, containerID)
+	}
+	s.archives.Remove(containerID)
+
 	jobDetails, exists := s.activeJobs[containerID]
 	if exists {
 		s.cron.Remove(jobDetails.cronID)
@@ -148,56 +235,165 @@ func (s *Scheduler) RemoveJob(containerID string) {
 
 func (s *Scheduler) jobFunc(containerID string, spec model.BackupSpec) func() {
 	return func() {
-		select {
-		case s.concurrencyLimit <- struct{}{}:
-		default:
-			logger.Log.Warn("Skipping backup due to concurrency limit reached",
+		resources := backupResources(spec)
+		waited, err := s.slots.acquire(containerID, spec.ContainerName, spec.Priority, resources, func(depth int) {
+			logger.Log.Info("Concurrency limit reached, backup queued",
 				zap.String("containerID", containerID),
 				zap.String("containerName", spec.ContainerName),
+				zap.Int("priority", spec.Priority),
+				zap.Strings("resources", resources),
+				zap.Int("queueDepth", depth),
 			)
+		})
+		if errors.Is(err, errQueueClosed) {
+			logger.Log.Info("Dropping queued backup, scheduler stopped",
+				zap.String("containerID", containerID),
+				zap.Duration("waited", waited),
+			)
+			return
+		} else if err != nil {
+			s.notifySkipped(containerID, spec, waited, err)
 			return
 		}
-		
-		defer func() {
-			<-s.concurrencyLimit
-		}()
+		defer s.slots.release(resources)
 
-		startTime := time.Now()
-		// Use configurable timeout for backup operations (default 30 minutes)
-		backupTimeout := 30 * time.Minute
-		if timeoutStr, ok := s.globalConfig["BACKUP_TIMEOUT_MINUTES"]; ok && timeoutStr != "" {
-			if timeout, err := strconv.Atoi(timeoutStr); err == nil && timeout > 0 {
-				backupTimeout = time.Duration(timeout) * time.Minute
-			} else {
-				logger.Log.Warn("Invalid BACKUP_TIMEOUT_MINUTES value, using default",
-					zap.String("value", timeoutStr),
-					zap.Duration("default", backupTimeout),
-					zap.Error(err),
-				)
-			}
+		if waited > 0 {
+			logger.Log.Info("Queued backup starting",
+				zap.String("containerID", containerID),
+				zap.String("containerName", spec.ContainerName),
+				zap.Duration("waited", waited),
+			)
 		}
-		jobCtx, cancel := context.WithTimeout(context.Background(), backupTimeout)
-		defer cancel()
+		s.runBackup(containerID, spec, jobstore.TriggerSchedule, newJobID())
+	}
+}
 
-		logger.Log.Info("Starting backup job",
-			zap.String("containerID", containerID),
-			zap.String("dbType", spec.Type),
-			zap.String("containerName", spec.ContainerName),
-		)
+// notifySkipped reports a scheduled backup that never ran because no concurrency
+// slot became free.
+func (s *Scheduler) notifySkipped(containerID string, spec model.BackupSpec, waited time.Duration, reason error) {
+	logger.Log.Warn("Skipping backup, no concurrency slot available",
+		zap.String("containerID", containerID),
+		zap.String("containerName", spec.ContainerName),
+		zap.Duration("waited", waited),
+		zap.Error(reason),
+	)
+	metrics.BackupsTotal.WithLabelValues(spec.ContainerName, spec.Type, "skipped").Inc()
+	if s.webhookSender == nil {
+		return
+	}
+	s.webhookSender.Enqueue(webhook.NotificationPayload{
+		Event:           webhook.EventBackupSkipped,
+		Timestamp:       time.Now().UTC().Format(time.RFC3339),
+		ContainerID:     containerID,
+		ContainerName:   spec.ContainerName,
+		DatabaseType:    spec.Type,
+		DatabaseName:    spec.Database,
+		CronSchedule:    spec.Cron,
+		BackupPrefix:    spec.Prefix,
+		Success:         false,
+		Error:           fmt.Sprintf("%v after waiting %s", reason, waited.Round(time.Second)),
+		DurationSeconds: waited.Seconds(),
+	}, spec)
+}
 
-		payload := webhook.NotificationPayload{
-			Timestamp:       startTime.UTC().Format(time.RFC3339),
-			ContainerID:     containerID,
-			ContainerName:   spec.ContainerName,
-			DatabaseType:    spec.Type,
-			DatabaseName:    spec.Database,
-			CronSchedule:    spec.Cron,
-			BackupPrefix:    spec.Prefix,
+// QueueStatus reports slot usage and the backups waiting for a slot, in the
+// order they will run.
+func (s *Scheduler) QueueStatus() QueueStatus {
+	return s.slots.status()
+}
+
+// runBackup performs one backup, records it in the job history, sends its webhook
+// and returns the final payload. The caller is responsible for holding a
+// concurrency slot.
+func (s *Scheduler) runBackup(containerID string, spec model.BackupSpec, trigger string, jobID string) webhook.NotificationPayload {
+	startTime := time.Now()
+	// Use configurable timeout for backup operations (default 30 minutes)
+	backupTimeout := 30 * time.Minute
+	if timeoutStr, ok := s.globalConfig["BACKUP_TIMEOUT_MINUTES"]; ok && timeoutStr != "" {
+		if timeout, err := strconv.Atoi(timeoutStr); err == nil && timeout > 0 {
+			backupTimeout = time.Duration(timeout) * time.Minute
+		} else {
+			logger.Log.Warn("Invalid BACKUP_TIMEOUT_MINUTES value, using default",
+				zap.String("value", timeoutStr),
+				zap.Duration("default", backupTimeout),
+				zap.Error(err),
+			)
 		}
+	}
+	jobCtx, cancel := context.WithTimeout(context.Background(), backupTimeout)
+	defer cancel()
 
-		dbDumper, err := dumper.GetDumper(spec)
+	logger.Log.Info("Starting backup job",
+		zap.String("containerID", containerID),
+		zap.String("dbType", spec.Type),
+		zap.String("containerName", spec.ContainerName),
+	)
+
+	payload := webhook.NotificationPayload{
+		Event:           webhook.EventBackup,
+		Timestamp:       startTime.UTC().Format(time.RFC3339),
+		ContainerID:     containerID,
+		ContainerName:   spec.ContainerName,
+		DatabaseType:    spec.Type,
+		DatabaseName:    spec.Database,
+		CronSchedule:    spec.Cron,
+		BackupPrefix:    spec.Prefix,
+	}
+	record := jobstore.Record{
+		ID:            jobID,
+		ContainerID:   containerID,
+		ContainerName: spec.ContainerName,
+		DatabaseType:  spec.Type,
+		DatabaseName:  spec.Database,
+		Trigger:       trigger,
+		Status:        jobstore.StatusRunning,
+		StartedAt:     startTime.UTC(),
+		Phases:        make(map[string]float64),
+	}
+	s.recordHistory(record)
+	// Reads payload when the job returns, so every exit path is counted
+	defer func() {
+		recordBackupMetrics(spec, payload)
+		s.finishHistory(record, payload)
+	}()
+
+	dbDumper, err := dumper.GetDumper(spec)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to get dumper for %s: %v", spec.Type, err)
+		logger.Log.Error(errMsg, zap.String("containerID", containerID))
+		payload.Success = false
+		payload.Error = errMsg
+		payload.DurationSeconds = time.Since(startTime).Seconds()
+		if s.webhookSender != nil {
+			s.webhookSender.Enqueue(payload, spec)
+		}
+		return payload
+	}
+	logger.Log.Debug("Dumper obtained", zap.String("containerID", containerID), zap.String("type", spec.Type))
+
+	backupWriter, err := writer.GetWriter(spec, s.globalConfig)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to get writer for %s: %v", spec.Dest, err)
+		logger.Log.Error(errMsg, zap.String("containerID", containerID))
+		payload.Success = false
+		payload.Error = errMsg
+		payload.DurationSeconds = time.Since(startTime).Seconds()
+		if s.webhookSender != nil {
+			s.webhookSender.Enqueue(payload, spec)
+		}
+		return payload
+	}
+	payload.DestinationType = backupWriter.Type()
+	logger.Log.Debug("Writer obtained", zap.String("containerID", containerID), zap.String("type", backupWriter.Type()))
+
+	objectName := writer.GenerateObjectName(spec)
+	record.ObjectName = objectName
+
+	var encryptor encryption.Encryptor
+	if spec.Encrypt {
+		encryptor, err = encryption.GetEncryptor(spec, s.globalConfig)
 		if err != nil {
-			errMsg := fmt.Sprintf("Failed to get dumper for %s: %v", spec.Type, err)
+			errMsg := fmt.Sprintf("Encryption requested via backup.encrypt but no usable encryptor is configured: %v", err)
 			logger.Log.Error(errMsg, zap.String("containerID", containerID))
 			payload.Success = false
 			payload.Error = errMsg
@@ -205,198 +401,621 @@ func (s *Scheduler) jobFunc(containerID string, spec model.BackupSpec) func() {
 			if s.webhookSender != nil {
 				s.webhookSender.Enqueue(payload, spec)
 			}
-			return
+			return payload
 		}
-		logger.Log.Debug("Dumper obtained", zap.String("containerID", containerID), zap.String("type", spec.Type))
+		objectName += encryptor.GetEncryptedExtension()
+		record.ObjectName = objectName
+		logger.Log.Debug("Backup will be encrypted", zap.String("containerID", containerID), zap.String("encryptionType", encryptor.Type()), zap.String("objectName", objectName))
+	}
 
-		// Test database connection before proceeding with backup
-		if err := dbDumper.TestConnection(jobCtx, spec); err != nil {
-			errMsg := fmt.Sprintf("Database connection test failed for %s: %v", spec.Type, err)
-			logger.Log.Error(errMsg, zap.String("containerID", containerID))
-			payload.Success = false
-			payload.Error = errMsg
-			payload.DurationSeconds = time.Since(startTime).Seconds()
-			if s.webhookSender != nil {
-				s.webhookSender.Enqueue(payload, spec)
+	var attempt backupAttempt
+	var held *heldHook
+	var preHook *model.HookResult
+	if spec.PreExecHold && spec.PreExec != "" {
+		held = s.holdHook(jobCtx, containerID, spec)
+		preHook = held.result
+	} else {
+		preHook = s.runHook(jobCtx, containerID, spec, HookPre, nil)
+	}
+	if preHook != nil && preHook.Error != "" {
+		attempt.err = fmt.Errorf("pre-backup hook failed, backup aborted: %s", preHook.Error)
+	}
+	if attempt.err == nil {
+		attempt = s.attemptWithRetries(jobCtx, containerID, spec, dbDumper, backupWriter, encryptor, objectName, &payload, &record)
+	}
+	// A held pre hook is released before the post hook runs
+	if held != nil && held.session != nil {
+		s.releaseHook(containerID, spec, held)
+		if held.result.Error != "" && attempt.err == nil {
+			attempt.err = fmt.Errorf("pre-backup hook did not hold until the backup finished: %s", held.result.Error)
+		}
+	}
+	if preHook != nil {
+		payload.Hooks = append(payload.Hooks, *preHook)
+	}
+	// The post hook also runs after a failure or timeout, so it gets its own deadline
+	if hook := s.runHook(context.Background(), containerID, spec, HookPost, postHookEnv(attempt.err == nil)); hook != nil {
+		payload.Hooks = append(payload.Hooks, *hook)
+		if hook.Error != "" {
+			payload.Warning = fmt.Sprintf("post-backup hook failed: %s", hook.Error)
+		}
+	}
+
+	jobSuccess := attempt.err == nil
+	finalErrorMsg := ""
+	if !jobSuccess {
+		finalErrorMsg = attempt.err.Error()
+	}
+	bytesWritten := attempt.bytesWritten
+	destinationURL := attempt.destinationURL
+	backupChecksum := attempt.checksum
+
+	// Update payload with final results
+	payload.Success = jobSuccess
+	payload.DurationSeconds = time.Since(startTime).Seconds()
+	payload.BackupSize = bytesWritten
+	payload.DestinationURL = destinationURL 
+	if !jobSuccess {
+		payload.Error = finalErrorMsg
+	} else {
+		record.Checksum = backupChecksum
+	}
+
+	for _, obj := range attempt.objects {
+		if obj.err == nil {
+			payload.Objects = append(payload.Objects, obj.name)
+		}
+	}
+
+	// Only write metadata for successful backups, and for the parts that succeeded
+	// in a split backup
+	if (jobSuccess || len(payload.Objects) > 0) && bytesWritten > 0 {
+		metadata := writer.BackupMetadata{
+			Timestamp:       startTime,
+			ContainerID:     containerID,
+			ContainerName:   spec.ContainerName,
+			DatabaseType:    spec.Type,
+			DatabaseName:    spec.Database,
+			BackupSize:      bytesWritten,
+			Checksum:        backupChecksum,
+			CompressionType: "gzip",
+			Version:         "1.0",
+			Destination:     destinationURL,
+			DurationSeconds: payload.DurationSeconds,
+			Success:         jobSuccess,
+			Error:           payload.Error,
+		}
+		if spec.Encrypt {
+			metadata.Encrypted = true
+			metadata.EncryptionType = encryptor.Type()
+			metadata.EncryptionKey = encryptor.KeyInfo()
+		}
+		if positioned, ok := dbDumper.(dumper.PositionDumper); ok && jobSuccess {
+			if position := positioned.BinlogPosition(); position != nil {
+				position.Archive = writer.BinlogKeyPrefix(spec)
+				metadata.Binlog = position
 			}
-			return
 		}
-		logger.Log.Debug("Database connection test successful", zap.String("containerID", containerID), zap.String("type", spec.Type))
+		if positioned, ok := dbDumper.(dumper.WALDumper); ok && jobSuccess {
+			if position := positioned.WALPosition(); position != nil {
+				position.Archive = writer.WALKeyPrefix(spec)
+				metadata.WAL = position
+			}
+		}
+		
+		phaseStart := time.Now()
+		if len(attempt.objects) > 0 {
+			writePartMetadata(jobCtx, backupWriter, metadata, jobID, attempt.objects)
+		} else if err := writer.WriteMetadata(jobCtx, backupWriter, metadata, objectName); err != nil {
+			logger.Log.Warn("Failed to write backup metadata", 
+				zap.String("containerID", containerID),
+				zap.String("objectName", objectName),
+				zap.Error(err),
+			)
+		}
+		record.Phases[jobstore.PhaseMetadata] = time.Since(phaseStart).Seconds()
+	} 
+
+	if jobSuccess {
+		logger.Log.Info("Backup job write completed successfully",
+			zap.String("containerID", containerID),
+			zap.String("objectName", objectName), 
+			zap.Int64("bytesWritten", bytesWritten),
+			zap.String("destination", destinationURL),
+			zap.String("checksum", backupChecksum),
+		)
+	} else {
+		logger.Log.Error("Backup job failed overall",
+			zap.String("containerID", containerID),
+			zap.String("finalErrorSummary", finalErrorMsg), 
+		)
+	}
 
-		backupWriter, err := writer.GetWriter(spec, s.globalConfig)
+	logger.Log.Info("Backup job finished processing",
+		zap.String("containerID", containerID),
+		zap.Bool("success", payload.Success),
+		zap.Float64("durationSeconds", payload.DurationSeconds),
+		zap.Int64("sizeBytes", payload.BackupSize), 
+		zap.String("destinationURL", payload.DestinationURL),
+		zap.String("error", payload.Error),
+	)
+
+	if s.webhookSender != nil {
+		s.webhookSender.Enqueue(payload, spec)
+	} else {
+		logger.Log.Warn("Webhook sender is not initialized, cannot send notification", zap.String("containerID", containerID))
+	}
+	return payload
+}
+
+// attemptWithRetries runs backup attempts until one succeeds, the retry policy
+// of spec is exhausted or jobCtx ends, and returns the last attempt.
+func (s *Scheduler) attemptWithRetries(jobCtx context.Context, containerID string, spec model.BackupSpec, dbDumper dumper.Dumper, backupWriter writer.BackupWriter, encryptor encryption.Encryptor, objectName string, payload *webhook.NotificationPayload, record *jobstore.Record) backupAttempt {
+	maxRetries, backoff, maxBackoff := s.retryPolicy(spec)
+	var attempt backupAttempt
+	// Parts of a split backup that succeeded are kept, so retries only redo the rest
+	completed := make(map[string]partObject)
+	for n := 1; ; n++ {
+		payload.Attempts = n
+		attempt = s.attemptBackup(jobCtx, containerID, spec, dbDumper, backupWriter, encryptor, objectName, completed, record)
+		if attempt.err == nil {
+			break
+		}
+		if len(attempt.objects) == 0 && attempt.bytesWritten > 0 {
+			cleanupPartialBackup(jobCtx, backupWriter, containerID, objectName)
+		}
+		if n > maxRetries || jobCtx.Err() != nil {
+			break
+		}
+
+		delay := backoffDelay(n, backoff, maxBackoff)
+		logger.Log.Warn("Backup attempt failed, retrying",
+			zap.String("containerID", containerID),
+			zap.Int("attempt", n),
+			zap.Int("maxRetries", maxRetries),
+			zap.Duration("backoff", delay),
+			zap.Error(attempt.err),
+		)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-jobCtx.Done():
+			timer.Stop()
+		}
+		if jobCtx.Err() != nil {
+			break
+		}
+	}
+	// A last attempt that failed before reaching the parts would leave the parts
+	// kept from earlier attempts without metadata
+	if attempt.err != nil && len(attempt.objects) == 0 {
+		for name := range completed {
+			cleanupPartialBackup(jobCtx, backupWriter, containerID, name)
+		}
+	}
+	return attempt
+}
+
+// backupAttempt is the outcome of one connection test, dump and write pass.
+type backupAttempt struct {
+	destinationURL string
+	bytesWritten   int64
+	checksum       string
+	// objects is set when the backup was split into parts, with one entry per part
+	objects       []partObject
+	dumpDuration  time.Duration
+	writeDuration time.Duration
+	err           error
+}
+
+// partObject is one part of a split backup, written or failed.
+type partObject struct {
+	part           dumper.Part
+	name           string
+	destinationURL string
+	bytesWritten   int64
+	checksum       string
+	err            error
+}
+
+// attemptBackup tests the connection and streams the dump into the writer, one
+// object per part when the dumper splits spec into parts. It records phase
+// timings of the latest attempt in record.
+func (s *Scheduler) attemptBackup(jobCtx context.Context, containerID string, spec model.BackupSpec, dbDumper dumper.Dumper, backupWriter writer.BackupWriter, encryptor encryption.Encryptor, objectName string, completed map[string]partObject, record *jobstore.Record) backupAttempt {
+	phaseStart := time.Now()
+	err := dbDumper.TestConnection(jobCtx, spec)
+	record.Phases[jobstore.PhaseTestConnection] = time.Since(phaseStart).Seconds()
+	if err != nil {
+		errMsg := fmt.Sprintf("Database connection test failed for %s: %v", spec.Type, err)
+		logger.Log.Error(errMsg, zap.String("containerID", containerID))
+		return backupAttempt{err: errors.New(errMsg)}
+	}
+	logger.Log.Debug("Database connection test successful", zap.String("containerID", containerID), zap.String("type", spec.Type))
+
+	var parts []dumper.Part
+	if multi, ok := dbDumper.(dumper.MultiDumper); ok {
+		parts, err = multi.Parts(jobCtx, spec)
 		if err != nil {
-			errMsg := fmt.Sprintf("Failed to get writer for %s: %v", spec.Dest, err)
+			errMsg := fmt.Sprintf("Failed to determine backup parts for %s: %v", spec.Type, err)
 			logger.Log.Error(errMsg, zap.String("containerID", containerID))
-			payload.Success = false
-			payload.Error = errMsg
-			payload.DurationSeconds = time.Since(startTime).Seconds()
-			if s.webhookSender != nil {
-				s.webhookSender.Enqueue(payload, spec)
+			return backupAttempt{err: errors.New(errMsg)}
+		}
+	}
+
+	var result backupAttempt
+	if len(parts) == 0 {
+		result = s.streamObject(jobCtx, containerID, spec, dbDumper, backupWriter, encryptor, objectName)
+	} else {
+		result = s.streamParts(jobCtx, containerID, parts, backupWriter, encryptor, objectName, completed)
+	}
+	// Dump and write stream through a pipe, so both are measured from the same start
+	record.Phases[jobstore.PhaseDump] = result.dumpDuration.Seconds()
+	record.Phases[jobstore.PhaseWrite] = result.writeDuration.Seconds()
+	return result
+}
+
+// streamParts writes each part to its own object named after objectName, one
+// after another. A failed part is cleaned up and does not stop the others;
+// parts in completed were written by an earlier attempt and are not dumped
+// again.
+func (s *Scheduler) streamParts(jobCtx context.Context, containerID string, parts []dumper.Part, backupWriter writer.BackupWriter, encryptor encryption.Encryptor, objectName string, completed map[string]partObject) backupAttempt {
+	names := make(map[string]string)
+	for _, part := range parts {
+		name := writer.PartObjectName(objectName, part.Name)
+		if other, ok := names[name]; ok {
+			return backupAttempt{err: fmt.Errorf("backup parts %q and %q map to the same object %s", other, part.Name, name)}
+		}
+		names[name] = part.Name
+	}
+
+	var result backupAttempt
+	var failures []string
+	for _, part := range parts {
+		name := writer.PartObjectName(objectName, part.Name)
+		if done, ok := completed[name]; ok {
+			result.objects = append(result.objects, done)
+			result.bytesWritten += done.bytesWritten
+			continue
+		}
+		obj := partObject{part: part, name: name}
+		if jobCtx.Err() != nil {
+			obj.err = fmt.Errorf("backup cancelled: %w", jobCtx.Err())
+		} else {
+			logger.Log.Info("Backing up part",
+				zap.String("containerID", containerID),
+				zap.String("part", part.Name),
+				zap.String("kind", part.Kind),
+				zap.String("objectName", name),
+			)
+			attempt := s.streamObject(jobCtx, containerID, part.Spec, part.Dumper, backupWriter, encryptor, name)
+			result.dumpDuration += attempt.dumpDuration
+			result.writeDuration += attempt.writeDuration
+			obj.destinationURL = attempt.destinationURL
+			obj.bytesWritten = attempt.bytesWritten
+			obj.checksum = attempt.checksum
+			obj.err = attempt.err
+			if attempt.err != nil && attempt.bytesWritten > 0 {
+				cleanupPartialBackup(jobCtx, backupWriter, containerID, name)
 			}
-			return
 		}
-		payload.DestinationType = backupWriter.Type()
-		logger.Log.Debug("Writer obtained", zap.String("containerID", containerID), zap.String("type", backupWriter.Type()))
+		if obj.err != nil {
+			obj.bytesWritten = 0
+			failures = append(failures, fmt.Sprintf("%s: %v", part.Name, obj.err))
+		} else {
+			completed[name] = obj
+			result.bytesWritten += obj.bytesWritten
+			if result.destinationURL == "" {
+				result.destinationURL = obj.destinationURL
+			}
+		}
+		result.objects = append(result.objects, obj)
+	}
+	if len(failures) > 0 {
+		result.err = fmt.Errorf("%d of %d parts failed: %s", len(failures), len(parts), strings.Join(failures, "; "))
+	}
+	return result
+}
 
-		pr, pw := io.Pipe()
+// streamObject streams one dump into the writer.
+func (s *Scheduler) streamObject(jobCtx context.Context, containerID string, spec model.BackupSpec, dbDumper dumper.Dumper, backupWriter writer.BackupWriter, encryptor encryption.Encryptor, objectName string) backupAttempt {
+	pr, pw := io.Pipe()
 
-		var bytesWritten int64
-		var writeErr error
-		var backupChecksum string
-		var dumpErr error
-		var destinationURL string
-		var wg sync.WaitGroup
-		wg.Add(2)
-		
-		objectName := writer.GenerateObjectName(spec)
-
-		go func() {
-			defer wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					logger.Log.Error("Panic in dumper goroutine", zap.Any("panic", r), zap.String("containerID", containerID))
-					dumpErr = fmt.Errorf("panic: %v", r)
-				}
-				if errClosePipe := pw.Close(); errClosePipe != nil && errClosePipe != io.ErrClosedPipe {
-					logger.Log.Error("Error closing pipe writer in dumper goroutine", zap.Error(errClosePipe), zap.String("containerID", containerID))
-				}
-			}()
-			
-			// Monitor context cancellation
-			select {
-			case <-jobCtx.Done():
-				dumpErr = fmt.Errorf("backup cancelled: %w", jobCtx.Err())
-				logger.Log.Warn("Backup cancelled during dump", zap.String("containerID", containerID), zap.Error(jobCtx.Err()))
-				return
-			default:
+	var bytesWritten int64
+	var writeErr error
+	var encryptErr error
+	var backupChecksum string
+	var dumpErr error
+	var destinationURL string
+	var dumpDuration, writeDuration time.Duration
+	streamStart := time.Now()
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Log.Error("Panic in dumper goroutine", zap.Any("panic", r), zap.String("containerID", containerID))
+				dumpErr = fmt.Errorf("panic: %v", r)
 			}
-			
-			dumpErr = dbDumper.Dump(jobCtx, spec, pw)
-			if dumpErr != nil {
-				logger.Log.Error("Dumper failed", zap.Error(dumpErr), zap.String("containerID", containerID))
-				_ = pw.CloseWithError(dumpErr)
-			} else {
-				logger.Log.Info("Dump completed successfully by dumper goroutine", zap.String("containerID", containerID))
+			if errClosePipe := pw.Close(); errClosePipe != nil && errClosePipe != io.ErrClosedPipe {
+				logger.Log.Error("Error closing pipe writer in dumper goroutine", zap.Error(errClosePipe), zap.String("containerID", containerID))
 			}
 		}()
+		
+		// Monitor context cancellation
+		select {
+		case <-jobCtx.Done():
+			dumpErr = fmt.Errorf("backup cancelled: %w", jobCtx.Err())
+			logger.Log.Warn("Backup cancelled during dump", zap.String("containerID", containerID), zap.Error(jobCtx.Err()))
+			return
+		default:
+		}
+		
+		dumpErr = dbDumper.Dump(jobCtx, spec, pw)
+		dumpDuration = time.Since(streamStart)
+		if dumpErr != nil {
+			logger.Log.Error("Dumper failed", zap.Error(dumpErr), zap.String("containerID", containerID))
+			_ = pw.CloseWithError(dumpErr)
+		} else {
+			logger.Log.Info("Dump completed successfully by dumper goroutine", zap.String("containerID", containerID))
+		}
+	}()
+
+	go func() {
+	    defer wg.Done()
+	    defer func() {
+		    if r := recover(); r != nil {
+			    logger.Log.Error("Panic in writer goroutine", zap.Any("panic", r), zap.String("containerID", containerID))
+			    writeErr = fmt.Errorf("panic: %v", r)
+		    }
+		    // Unblock the dumper if we stopped reading before it finished
+		    _ = pr.CloseWithError(fmt.Errorf("backup writer stopped reading"))
+	    }()
+	    
+	    // Monitor context cancellation
+	    select {
+	    case <-jobCtx.Done():
+		    writeErr = fmt.Errorf("backup cancelled: %w", jobCtx.Err())
+		    logger.Log.Warn("Backup cancelled during write", zap.String("containerID", containerID), zap.Error(jobCtx.Err()))
+		    return
+	    default:
+	    }
 
-		go func() {
-		    defer wg.Done()
-		    defer func() {
-			    if r := recover(); r != nil {
-				    logger.Log.Error("Panic in writer goroutine", zap.Any("panic", r), zap.String("containerID", containerID))
-				    writeErr = fmt.Errorf("panic: %v", r)
-			    }
-		    }()
-		    
-		    // Monitor context cancellation
-		    select {
-		    case <-jobCtx.Done():
-			    writeErr = fmt.Errorf("backup cancelled: %w", jobCtx.Err())
-			    logger.Log.Warn("Backup cancelled during write", zap.String("containerID", containerID), zap.Error(jobCtx.Err()))
+	    var source io.Reader = pr
+	    var encryptedReader io.ReadCloser
+	    if spec.Encrypt {
+		    encryptedReader, encryptErr = encryptor.Encrypt(jobCtx, pr)
+		    if encryptErr != nil {
+			    logger.Log.Error("Failed to start encryption", zap.Error(encryptErr), zap.String("containerID", containerID))
 			    return
-		    default:
 		    }
-		    
-		    destinationURL, bytesWritten, backupChecksum, writeErr = backupWriter.Write(jobCtx, objectName, pr)
-		}()
+		    source = encryptedReader
+	    }
+	    
+	    destinationURL, bytesWritten, backupChecksum, writeErr = backupWriter.Write(jobCtx, objectName, source)
+	    writeDuration = time.Since(streamStart)
 
-		wg.Wait() 
+	    if encryptedReader != nil {
+		    if writeErr != nil {
+			    _ = pr.CloseWithError(writeErr)
+		    }
+		    if errClose := encryptedReader.Close(); errClose != nil {
+			    encryptErr = errClose
+			    logger.Log.Error("Encryption failed", zap.Error(errClose), zap.String("containerID", containerID), zap.String("objectName", objectName))
+		    }
+	    }
+	}()
 
-		finalErrorMsg := ""
-		jobSuccess := true
+	wg.Wait()
 
-		if dumpErr != nil {
-			finalErrorMsg = fmt.Sprintf("dump error: %v", dumpErr)
-			jobSuccess = false
+	finalErrorMsg := ""
+
+	if dumpErr != nil {
+		finalErrorMsg = fmt.Sprintf("dump error: %v", dumpErr)
+	}
+	if writeErr != nil {
+		if finalErrorMsg != "" {
+			finalErrorMsg += "; "
 		}
-		if writeErr != nil {
-			if finalErrorMsg != "" {
-				finalErrorMsg += "; "
-			}
-			finalErrorMsg += fmt.Sprintf("write error: %v", writeErr)
-			jobSuccess = false
-			logger.Log.Error("Writer failed", zap.Error(writeErr), zap.String("containerID", containerID), zap.String("objectName", objectName))
+		finalErrorMsg += fmt.Sprintf("write error: %v", writeErr)
+		logger.Log.Error("Writer failed", zap.Error(writeErr), zap.String("containerID", containerID), zap.String("objectName", objectName))
+	}
+	if encryptErr != nil {
+		if finalErrorMsg != "" {
+			finalErrorMsg += "; "
 		}
+		finalErrorMsg += fmt.Sprintf("encryption error: %v", encryptErr)
+	}
 
-		// Update payload with final results
-		payload.Success = jobSuccess
-		payload.DurationSeconds = time.Since(startTime).Seconds()
-		payload.BackupSize = bytesWritten
-		payload.DestinationURL = destinationURL 
-		if !jobSuccess {
-			payload.Error = finalErrorMsg
-		}
-
-		// Only write metadata for successful backups
-		if jobSuccess && bytesWritten > 0 {
-			metadata := writer.BackupMetadata{
-				Timestamp:       startTime,
-				ContainerID:     containerID,
-				ContainerName:   spec.ContainerName,
-				DatabaseType:    spec.Type,
-				DatabaseName:    spec.Database,
-				BackupSize:      bytesWritten,
-				Checksum:        backupChecksum,
-				CompressionType: "gzip",
-				Version:         "1.0",
-				Destination:     destinationURL,
-				DurationSeconds: payload.DurationSeconds,
-				Success:         jobSuccess,
-				Error:           payload.Error,
-			}
-			
-			if err := writer.WriteMetadata(jobCtx, backupWriter, metadata, objectName); err != nil {
-				logger.Log.Warn("Failed to write backup metadata", 
-					zap.String("containerID", containerID),
-					zap.String("objectName", objectName),
-					zap.Error(err),
-				)
-			}
-		} else if !jobSuccess && bytesWritten > 0 {
-			// Cleanup partial backup on failure
-			if err := backupWriter.DeleteObject(jobCtx, objectName); err != nil {
-				logger.Log.Warn("Failed to cleanup partial backup",
-					zap.String("containerID", containerID),
-					zap.String("objectName", objectName),
-					zap.Error(err),
-				)
+	result := backupAttempt{
+		destinationURL: destinationURL,
+		bytesWritten:   bytesWritten,
+		checksum:       backupChecksum,
+		dumpDuration:   dumpDuration,
+		writeDuration:  writeDuration,
+	}
+	if finalErrorMsg != "" {
+		result.err = errors.New(finalErrorMsg)
+	}
+	return result
+}
+
+// writePartMetadata writes the metadata of every stored object of a split backup.
+// Each describes its own object and carries the manifest of the whole run, which
+// also lists the parts that failed.
+func writePartMetadata(ctx context.Context, backupWriter writer.BackupWriter, metadata writer.BackupMetadata, runID string, objects []partObject) {
+	manifest := &writer.Manifest{RunID: runID}
+	for _, obj := range objects {
+		entry := writer.ManifestEntry{
+			Part:     obj.part.Name,
+			Kind:     obj.part.Kind,
+			Database: obj.part.Database,
+			Status:   writer.ManifestStatusSucceeded,
+		}
+		if obj.err != nil {
+			entry.Status = writer.ManifestStatusFailed
+			entry.Error = obj.err.Error()
+		} else {
+			entry.Object = obj.name
+			entry.Size = obj.bytesWritten
+			entry.Checksum = obj.checksum
+		}
+		manifest.Objects = append(manifest.Objects, entry)
+	}
+
+	for _, obj := range objects {
+		if obj.err != nil {
+			continue
+		}
+		partMetadata := metadata
+		partMetadata.Success = true
+		partMetadata.Error = ""
+		partMetadata.DatabaseName = obj.part.Database
+		partMetadata.BackupSize = obj.bytesWritten
+		partMetadata.Checksum = obj.checksum
+		partMetadata.Destination = obj.destinationURL
+		partMetadata.Part = obj.part.Name
+		partMetadata.Manifest = manifest
+		if err := writer.WriteMetadata(ctx, backupWriter, partMetadata, obj.name); err != nil {
+			logger.Log.Warn("Failed to write backup metadata",
+				zap.String("containerID", metadata.ContainerID),
+				zap.String("objectName", obj.name),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+func cleanupPartialBackup(ctx context.Context, backupWriter writer.BackupWriter, containerID string, objectName string) {
+	if err := backupWriter.DeleteObject(ctx, objectName); err != nil {
+		logger.Log.Warn("Failed to cleanup partial backup",
+			zap.String("containerID", containerID),
+			zap.String("objectName", objectName),
+			zap.Error(err),
+		)
+	} else {
+		logger.Log.Info("Cleaned up partial backup",
+			zap.String("containerID", containerID),
+			zap.String("objectName", objectName),
+		)
+	}
+}
+
+func (s *Scheduler) recordHistory(record jobstore.Record) {
+	if s.history == nil {
+		return
+	}
+	if err := s.history.Put(record); err != nil {
+		logger.Log.Warn("Failed to record job history",
+			zap.String("jobID", record.ID),
+			zap.String("containerID", record.ContainerID),
+			zap.Error(err),
+		)
+	}
+}
+
+func (s *Scheduler) finishHistory(record jobstore.Record, payload webhook.NotificationPayload) {
+	finishedAt := time.Now().UTC()
+	record.FinishedAt = &finishedAt
+	record.DurationSeconds = payload.DurationSeconds
+	record.Attempts = payload.Attempts
+	record.BytesWritten = payload.BackupSize
+	record.Destination = payload.DestinationURL
+	record.Hooks = payload.Hooks
+	if len(payload.Objects) > 0 {
+		record.Objects = payload.Objects
+		record.ObjectName = payload.Objects[0]
+	}
+	record.Error = payload.Error
+	record.Status = jobstore.StatusFailed
+	if payload.Success {
+		record.Status = jobstore.StatusSucceeded
+	}
+	s.recordHistory(record)
+}
+
+func recordBackupMetrics(spec model.BackupSpec, payload webhook.NotificationPayload) {
+	status := "failure"
+	if payload.Success {
+		status = "success"
+		metrics.BackupLastSuccessTimestamp.WithLabelValues(spec.ContainerName, spec.Type).SetToCurrentTime()
+		metrics.BackupBytesWritten.WithLabelValues(spec.ContainerName, spec.Type).Add(float64(payload.BackupSize))
+	}
+	metrics.BackupsTotal.WithLabelValues(spec.ContainerName, spec.Type, status).Inc()
+	metrics.BackupDuration.WithLabelValues(spec.ContainerName, spec.Type).Observe(payload.DurationSeconds)
+}
+
+func (s *Scheduler) verifyJobFunc(containerID string, spec model.BackupSpec) func() {
+	return func() {
+		// Drills download from the destination but do not touch the source host
+		resources := []string{destResource(spec)}
+		if !s.slots.tryAcquire(resources) {
+			logger.Log.Warn("Skipping restore drill due to concurrency limit reached",
+				zap.String("containerID", containerID),
+				zap.String("containerName", spec.ContainerName),
+			)
+			return
+		}
+		defer s.slots.release(resources)
+
+		startTime := time.Now()
+		verifyTimeout := 60 * time.Minute
+		if timeoutStr, ok := s.globalConfig["VERIFY_TIMEOUT_MINUTES"]; ok && timeoutStr != "" {
+			if timeout, err := strconv.Atoi(timeoutStr); err == nil && timeout > 0 {
+				verifyTimeout = time.Duration(timeout) * time.Minute
 			} else {
-				logger.Log.Info("Cleaned up partial backup",
-					zap.String("containerID", containerID),
-					zap.String("objectName", objectName),
+				logger.Log.Warn("Invalid VERIFY_TIMEOUT_MINUTES value, using default",
+					zap.String("value", timeoutStr),
+					zap.Duration("default", verifyTimeout),
+					zap.Error(err),
 				)
 			}
-		} 
+		}
+		jobCtx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+		defer cancel()
+
+		logger.Log.Info("Starting restore drill",
+			zap.String("containerID", containerID),
+			zap.String("dbType", spec.Type),
+			zap.String("image", spec.VerifyImage),
+		)
+
+		payload := webhook.NotificationPayload{
+			Event:         webhook.EventVerify,
+			Timestamp:     startTime.UTC().Format(time.RFC3339),
+			ContainerID:   containerID,
+			ContainerName: spec.ContainerName,
+			DatabaseType:  spec.Type,
+			DatabaseName:  spec.Database,
+			CronSchedule:  spec.VerifyCron,
+			BackupPrefix:  spec.Prefix,
+		}
 
-		if jobSuccess {
-			logger.Log.Info("Backup job write completed successfully",
+		v := verifier.NewVerifier(s.discoveryWatcher.DockerClient(), s.globalConfig)
+		result, err := v.Verify(jobCtx, spec)
+		if result != nil {
+			payload.ObjectName = result.Object
+		}
+		payload.Success = err == nil
+		payload.DurationSeconds = time.Since(startTime).Seconds()
+		if err != nil {
+			payload.Error = err.Error()
+			logger.Log.Error("Restore drill failed",
 				zap.String("containerID", containerID),
-				zap.String("objectName", objectName), 
-				zap.Int64("bytesWritten", bytesWritten),
-				zap.String("destination", destinationURL),
-				zap.String("checksum", backupChecksum),
+				zap.String("object", payload.ObjectName),
+				zap.Error(err),
 			)
 		} else {
-			logger.Log.Error("Backup job failed overall",
+			logger.Log.Info("Restore drill passed",
 				zap.String("containerID", containerID),
-				zap.String("finalErrorSummary", finalErrorMsg), 
+				zap.String("object", payload.ObjectName),
+				zap.Float64("durationSeconds", payload.DurationSeconds),
 			)
 		}
 
-		logger.Log.Info("Backup job finished processing",
-			zap.String("containerID", containerID),
-			zap.Bool("success", payload.Success),
-			zap.Float64("durationSeconds", payload.DurationSeconds),
-			zap.Int64("sizeBytes", payload.BackupSize), 
-			zap.String("destinationURL", payload.DestinationURL),
-			zap.String("error", payload.Error),
-		)
-
 		if s.webhookSender != nil {
 			s.webhookSender.Enqueue(payload, spec)
-		} else {
-			logger.Log.Warn("Webhook sender is not initialized, cannot send notification", zap.String("containerID", containerID))
 		}
 	}
 }
@@ -404,6 +1023,9 @@ func (s *Scheduler) jobFunc(containerID string, spec model.BackupSpec) func() {
 func (s *Scheduler) Stop() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	// Wake queued backups so cron.Stop does not wait for them
+	s.slots.close()
+	s.archives.Stop()
 	if s.cron != nil {
 		logger.Log.Info("Stopping cron scheduler...")
 		ctx := s.cron.Stop()
@@ -0,0 +1,39 @@
+package scheduler
+
+import (
+	"testing"
+
+	"label-backup/internal/model"
+)
+
+func TestAddOrUpdateJobReschedulesChangedSpec(t *testing.T) {
+	s := NewScheduler(map[string]string{}, nil, nil, nil)
+	defer s.Stop()
+
+	spec := model.BackupSpec{Type: "postgres", ContainerName: "app-db", Cron: "0 2 * * *"}
+	if err := s.AddOrUpdateJob("abc123", spec); err != nil {
+		t.Fatalf("AddOrUpdateJob() error = %v", err)
+	}
+	firstID := s.activeJobs["abc123"].cronID
+
+	if err := s.AddOrUpdateJob("abc123", spec); err != nil {
+		t.Fatalf("AddOrUpdateJob() error = %v", err)
+	}
+	if id := s.activeJobs["abc123"].cronID; id != firstID {
+		t.Errorf("unchanged spec re-scheduled the job: cron entry %d, want %d", id, firstID)
+	}
+
+	// The cron entry holds the spec it runs with, so a label change under the
+	// same cron expression must replace it
+	spec.Priority = 5
+	if err := s.AddOrUpdateJob("abc123", spec); err != nil {
+		t.Fatalf("AddOrUpdateJob() error = %v", err)
+	}
+	job := s.activeJobs["abc123"]
+	if job.cronID == firstID || job.spec.Priority != 5 {
+		t.Errorf("changed spec kept cron entry %d with priority %d", job.cronID, job.spec.Priority)
+	}
+	if entries := len(s.cron.Entries()); entries != 1 {
+		t.Errorf("cron has %d entries, want 1", entries)
+	}
+}
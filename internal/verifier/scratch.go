@@ -0,0 +1,99 @@
+package verifier
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const scratchPassword = "labelbackup-verify"
+const scratchDatabase = "verify"
+
+// scratchProfile describes how to run a throwaway database of one type:
+// how to start it, how to reach it, and how to query it from inside.
+type scratchProfile struct {
+	env []string
+	// defaultQuery counts what the restore created; see checkDefaultQueryOutput.
+	defaultQuery string
+	// conn builds the connection string the restorer uses to reach the scratch container.
+	conn func(host string) string
+	// queryCmd wraps a sanity query in a command run inside the scratch container.
+	queryCmd func(query string) []string
+	// preload means the backup has to be in place before the server starts (redis RDB).
+	preload     bool
+	preloadDir  string
+	preloadFile string
+}
+
+var scratchProfiles = map[string]scratchProfile{
+	"postgres": {
+		env:          []string{"POSTGRES_PASSWORD=" + scratchPassword, "POSTGRES_DB=" + scratchDatabase},
+		defaultQuery: "SELECT count(*) FROM information_schema.tables WHERE table_schema NOT IN ('pg_catalog', 'information_schema')",
+		conn: func(host string) string {
+			return fmt.Sprintf("postgresql://postgres:%s@%s:5432/%s", scratchPassword, host, scratchDatabase)
+		},
+		queryCmd: func(query string) []string {
+			return []string{"psql", "-U", "postgres", "-d", scratchDatabase, "-v", "ON_ERROR_STOP=1", "-tAc", query}
+		},
+	},
+	"mysql": {
+		env: []string{
+			"MARIADB_ROOT_PASSWORD=" + scratchPassword,
+			"MYSQL_ROOT_PASSWORD=" + scratchPassword,
+			"MARIADB_DATABASE=" + scratchDatabase,
+			"MYSQL_DATABASE=" + scratchDatabase,
+		},
+		defaultQuery: fmt.Sprintf("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = '%s'", scratchDatabase),
+		conn: func(host string) string {
+			return fmt.Sprintf("mysql://root:%s@%s:3306/%s", scratchPassword, host, scratchDatabase)
+		},
+		queryCmd: func(query string) []string {
+			// MariaDB images ship "mariadb", MySQL images ship "mysql"
+			script := `if command -v mariadb >/dev/null 2>&1; then exec mariadb -uroot -N -e "$0" ` + scratchDatabase +
+				`; else exec mysql -uroot -N -e "$0" ` + scratchDatabase + `; fi`
+			return []string{"sh", "-c", script, query}
+		},
+	},
+	"mongodb": {
+		defaultQuery: "db.adminCommand({listDatabases: 1}).databases.filter(d => !['admin', 'config', 'local'].includes(d.name)).length",
+		conn: func(host string) string {
+			return fmt.Sprintf("mongodb://%s:27017", host)
+		},
+		queryCmd: func(query string) []string {
+			// mongosh replaced the legacy mongo shell in MongoDB 6
+			script := `if command -v mongosh >/dev/null 2>&1; then exec mongosh --quiet --eval "$0"; else exec mongo --quiet --eval "$0"; fi`
+			return []string{"sh", "-c", script, query}
+		},
+	},
+	"redis": {
+		defaultQuery: "DBSIZE",
+		queryCmd: func(query string) []string {
+			return append([]string{"redis-cli"}, strings.Fields(query)...)
+		},
+		preload:     true,
+		preloadDir:  "/data",
+		preloadFile: "dump.rdb",
+	},
+}
+
+// scratchEnv is passed to execs so client tools authenticate without flags.
+func scratchEnv() []string {
+	return []string{"MYSQL_PWD=" + scratchPassword, "PGPASSWORD=" + scratchPassword}
+}
+
+// checkDefaultQueryOutput fails the drill when the default query counted
+// nothing, as an empty restore exits 0 as well.
+func checkDefaultQueryOutput(stdout string) error {
+	output := strings.TrimSpace(stdout)
+	if i := strings.LastIndexByte(output, '\n'); i != -1 {
+		output = strings.TrimSpace(output[i+1:])
+	}
+	count, err := strconv.Atoi(output)
+	if err != nil {
+		return fmt.Errorf("sanity query returned %q, want a count", truncate(output))
+	}
+	if count == 0 {
+		return fmt.Errorf("sanity query found nothing in the restored backup")
+	}
+	return nil
+}
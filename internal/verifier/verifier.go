@@ -0,0 +1,396 @@
+package verifier
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"label-backup/internal/dockerexec"
+	"label-backup/internal/dumper"
+	"label-backup/internal/logger"
+	"label-backup/internal/model"
+	"label-backup/internal/restorer"
+	"label-backup/internal/writer"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"go.uber.org/zap"
+)
+
+const (
+	StatusPassed = "passed"
+	StatusFailed = "failed"
+)
+
+const scratchStartupTimeout = 3 * time.Minute
+const scratchPollInterval = 2 * time.Second
+const maxOutputLength = 1024
+
+type Result struct {
+	Object string
+	Status string
+	Output string
+}
+
+// Verifier runs restore drills: it restores the newest backup of a container
+// into a throwaway database container and runs a sanity query against it.
+type Verifier struct {
+	cli          *client.Client
+	globalConfig map[string]string
+}
+
+func NewVerifier(cli *client.Client, globalConfig map[string]string) *Verifier {
+	return &Verifier{cli: cli, globalConfig: globalConfig}
+}
+
+func (v *Verifier) Verify(ctx context.Context, spec model.BackupSpec) (*Result, error) {
+	profile, ok := scratchProfiles[spec.Type]
+	if !ok {
+		return nil, fmt.Errorf("restore drills are not supported for database type: %s", spec.Type)
+	}
+	if spec.VerifyImage == "" {
+		return nil, fmt.Errorf("no image available for the scratch %s container, set backup.verify.image", spec.Type)
+	}
+
+	backupWriter, err := writer.GetWriter(spec, v.globalConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get writer for %s: %w", spec.Dest, err)
+	}
+
+	objectName, err := latestBackup(ctx, backupWriter, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Object: objectName, Status: StatusPassed}
+	drillErr := v.drill(ctx, spec, profile, objectName, result)
+	if drillErr != nil {
+		result.Status = StatusFailed
+	}
+
+	recordCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	recordResult(recordCtx, backupWriter, objectName, drillErr)
+
+	return result, drillErr
+}
+
+// latestBackup returns the newest backup of spec whose metadata reports success.
+// Objects still being uploaded or left by a failed run have no such metadata and
+// would fail the drill for reasons unrelated to the backups that count.
+func latestBackup(ctx context.Context, backupWriter writer.BackupWriter, spec model.BackupSpec) (string, error) {
+	objects, err := backupWriter.ListObjects(ctx, spec.Prefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var candidates []writer.BackupObjectMeta
+	for _, obj := range objects {
+		if !writer.IsObjectOf(spec, obj.Key) || strings.HasSuffix(obj.Key, writer.MetadataSuffix) {
+			continue
+		}
+		// Globals of a cluster backup hold no tables to run the sanity query on
+		if writer.ObjectPart(obj.Key) == dumper.PostgresGlobalsPart {
+			continue
+		}
+		candidates = append(candidates, obj)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return backupTime(candidates[i]).After(backupTime(candidates[j]))
+	})
+
+	for _, obj := range candidates {
+		metadata, err := writer.ReadMetadata(ctx, backupWriter, obj.Key)
+		if err != nil {
+			logger.Log.Debug("Skipping backup without readable metadata for restore drill",
+				zap.String("containerID", spec.ContainerID),
+				zap.String("key", obj.Key),
+				zap.Error(err),
+			)
+			continue
+		}
+		if metadata.Success {
+			return obj.Key, nil
+		}
+	}
+	return "", fmt.Errorf("no successful backups found matching %s", writer.ObjectKeyPrefix(spec))
+}
+
+func backupTime(obj writer.BackupObjectMeta) time.Time {
+	if ts, ok := writer.ParseObjectTimestamp(obj.Key); ok {
+		return ts
+	}
+	return obj.LastModified
+}
+
+func (v *Verifier) drill(ctx context.Context, spec model.BackupSpec, profile scratchProfile, objectName string, result *Result) error {
+	// Join a network of the source container so the scratch container is reachable the same way
+	networkName := dockerexec.PrimaryNetwork(ctx, v.cli, spec.ContainerID)
+
+	containerID, err := v.createScratch(ctx, spec, profile, networkName)
+	if err != nil {
+		return err
+	}
+	defer v.removeScratch(containerID)
+
+	if profile.preload {
+		if err := v.preloadBackup(ctx, spec, profile, objectName, containerID); err != nil {
+			return err
+		}
+	}
+
+	if err := v.cli.ContainerStart(ctx, containerID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start scratch container: %w", err)
+	}
+
+	host, err := v.containerIP(ctx, containerID, networkName)
+	if err != nil {
+		return err
+	}
+
+	if err := v.waitReady(ctx, spec, profile, containerID, host); err != nil {
+		return err
+	}
+
+	if !profile.preload {
+		req := restorer.Request{
+			Object: objectName,
+			Dest:   spec.Dest,
+			Type:   spec.Type,
+			Target: profile.conn(host),
+		}
+		if _, err := restorer.RestoreObject(ctx, req, v.globalConfig); err != nil {
+			return fmt.Errorf("restore into scratch container failed: %w", err)
+		}
+	}
+
+	query := spec.VerifyQuery
+	if query == "" {
+		query = profile.defaultQuery
+	}
+	execResult, err := dockerexec.Run(ctx, v.cli, containerID, profile.queryCmd(query), scratchEnv())
+	if err != nil {
+		return fmt.Errorf("failed to run sanity query: %w", err)
+	}
+	if execResult.ExitCode != 0 {
+		return fmt.Errorf("sanity query exited with code %d: %s", execResult.ExitCode, truncate(strings.TrimSpace(execResult.Stderr)))
+	}
+	result.Output = truncate(strings.TrimSpace(execResult.Stdout))
+	if spec.VerifyQuery == "" {
+		if err := checkDefaultQueryOutput(execResult.Stdout); err != nil {
+			return err
+		}
+	}
+
+	logger.Log.Info("Restore drill sanity query succeeded",
+		zap.String("containerID", spec.ContainerID),
+		zap.String("object", objectName),
+		zap.String("output", result.Output),
+	)
+	return nil
+}
+
+func (v *Verifier) createScratch(ctx context.Context, spec model.BackupSpec, profile scratchProfile, networkName string) (string, error) {
+	shortID := spec.ContainerID
+	if len(shortID) > 12 {
+		shortID = shortID[:12]
+	}
+	name := fmt.Sprintf("label-backup-verify-%s-%d", shortID, time.Now().Unix())
+
+	config := &container.Config{
+		Image:  spec.VerifyImage,
+		Env:    profile.env,
+		Labels: map[string]string{"label-backup.verify": spec.ContainerID},
+	}
+	var networkingConfig *network.NetworkingConfig
+	if networkName != "" {
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{networkName: {}},
+		}
+	}
+
+	resp, err := v.cli.ContainerCreate(ctx, config, &container.HostConfig{}, networkingConfig, nil, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to create scratch container from image %s (the image must be available locally): %w", spec.VerifyImage, err)
+	}
+
+	logger.Log.Info("Created scratch container for restore drill",
+		zap.String("containerID", spec.ContainerID),
+		zap.String("scratchContainer", name),
+		zap.String("image", spec.VerifyImage),
+		zap.String("network", networkName),
+	)
+	return resp.ID, nil
+}
+
+func (v *Verifier) removeScratch(containerID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := v.cli.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+		logger.Log.Error("Failed to remove scratch container", zap.String("scratchContainerID", containerID), zap.Error(err))
+		return
+	}
+	logger.Log.Debug("Removed scratch container", zap.String("scratchContainerID", containerID))
+}
+
+// preloadBackup restores the backup to a local file and copies it into the
+// scratch container before it starts.
+func (v *Verifier) preloadBackup(ctx context.Context, spec model.BackupSpec, profile scratchProfile, objectName, containerID string) error {
+	tmpDir, err := os.MkdirTemp("", "label-backup-verify-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	localPath := filepath.Join(tmpDir, profile.preloadFile)
+	req := restorer.Request{
+		Object: objectName,
+		Dest:   spec.Dest,
+		Type:   spec.Type,
+		Target: localPath,
+	}
+	if _, err := restorer.RestoreObject(ctx, req, v.globalConfig); err != nil {
+		return fmt.Errorf("restore of %s failed: %w", objectName, err)
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open restored file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat restored file: %w", err)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{
+			Name:    profile.preloadFile,
+			Mode:    0644,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		if err == nil {
+			_, err = io.Copy(tw, f)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	if err := v.cli.CopyToContainer(ctx, containerID, profile.preloadDir, pr, types.CopyToContainerOptions{}); err != nil {
+		pr.CloseWithError(err)
+		return fmt.Errorf("failed to copy restored file into scratch container: %w", err)
+	}
+	return nil
+}
+
+func (v *Verifier) containerIP(ctx context.Context, containerID, networkName string) (string, error) {
+	inspect, err := v.cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect scratch container: %w", err)
+	}
+	if inspect.NetworkSettings != nil {
+		if ep, ok := inspect.NetworkSettings.Networks[networkName]; ok && ep.IPAddress != "" {
+			return ep.IPAddress, nil
+		}
+		for _, ep := range inspect.NetworkSettings.Networks {
+			if ep.IPAddress != "" {
+				return ep.IPAddress, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("scratch container has no IP address")
+}
+
+// waitReady polls until the scratch database accepts connections over the
+// network, which (unlike a socket check) skips the images' init-time server.
+func (v *Verifier) waitReady(ctx context.Context, spec model.BackupSpec, profile scratchProfile, containerID, host string) error {
+	deadline := time.Now().Add(scratchStartupTimeout)
+	var lastErr error
+
+	for {
+		inspect, err := v.cli.ContainerInspect(ctx, containerID)
+		if err == nil && inspect.State != nil && !inspect.State.Running {
+			return fmt.Errorf("scratch container exited during startup (exit code %d)", inspect.State.ExitCode)
+		}
+
+		if profile.preload {
+			lastErr = pingInContainer(ctx, v.cli, containerID, profile)
+		} else {
+			target := model.BackupSpec{Type: spec.Type, Conn: profile.conn(host)}
+			var r restorer.Restorer
+			if r, lastErr = restorer.GetRestorer(target); lastErr == nil {
+				lastErr = r.TestConnection(ctx, target)
+			}
+		}
+		if lastErr == nil {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("scratch container not ready after %s: %w", scratchStartupTimeout, lastErr)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(scratchPollInterval):
+		}
+	}
+}
+
+func pingInContainer(ctx context.Context, cli *client.Client, containerID string, profile scratchProfile) error {
+	res, err := dockerexec.Run(ctx, cli, containerID, profile.queryCmd("PING"), nil)
+	if err != nil {
+		return err
+	}
+	if res.ExitCode != 0 || !strings.Contains(res.Stdout, "PONG") {
+		return fmt.Errorf("not ready: %s", strings.TrimSpace(res.Stdout+res.Stderr))
+	}
+	return nil
+}
+
+func recordResult(ctx context.Context, backupWriter writer.BackupWriter, objectName string, drillErr error) {
+	metadata, err := writer.ReadMetadata(ctx, backupWriter, objectName)
+	if err != nil {
+		logger.Log.Warn("Failed to read metadata to record restore drill result",
+			zap.String("object", objectName),
+			zap.Error(err),
+		)
+		return
+	}
+
+	now := time.Now().UTC()
+	metadata.VerifiedAt = &now
+	metadata.VerifyStatus = StatusPassed
+	metadata.VerifyError = ""
+	if drillErr != nil {
+		metadata.VerifyStatus = StatusFailed
+		metadata.VerifyError = drillErr.Error()
+	}
+
+	if err := writer.WriteMetadata(ctx, backupWriter, *metadata, objectName); err != nil {
+		logger.Log.Warn("Failed to record restore drill result in metadata",
+			zap.String("object", objectName),
+			zap.Error(err),
+		)
+	}
+}
+
+func truncate(s string) string {
+	if len(s) > maxOutputLength {
+		return s[:maxOutputLength] + "..."
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package verifier
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"label-backup/internal/model"
+	"label-backup/internal/writer"
+)
+
+func TestLatestBackup(t *testing.T) {
+	ctx := context.Background()
+	cfg := map[string]string{writer.GlobalConfigKeyLocalPath: t.TempDir()}
+	backupWriter, err := writer.GetWriter(model.BackupSpec{Dest: "local"}, cfg)
+	if err != nil {
+		t.Fatalf("GetWriter() error = %v", err)
+	}
+	spec := model.BackupSpec{Type: "postgres", Conn: "postgresql://u:p@db:5432/app", Prefix: "app"}
+
+	backups := []struct {
+		key     string
+		success bool
+		sidecar bool
+	}{
+		{key: "app/postgres-app-20250101020000.dump.gz", success: true, sidecar: true},
+		{key: "app/postgres-app-20250102020000.dump.gz", success: false, sidecar: true},
+		// Still being uploaded
+		{key: "app/postgres-app-20250103020000.dump.gz"},
+	}
+	for _, b := range backups {
+		if _, _, _, err := backupWriter.Write(ctx, b.key, strings.NewReader("dump")); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if b.sidecar {
+			if err := writer.WriteMetadata(ctx, backupWriter, writer.BackupMetadata{Success: b.success}, b.key); err != nil {
+				t.Fatalf("WriteMetadata() error = %v", err)
+			}
+		}
+	}
+
+	key, err := latestBackup(ctx, backupWriter, spec)
+	if err != nil {
+		t.Fatalf("latestBackup() error = %v", err)
+	}
+	if want := backups[0].key; key != want {
+		t.Errorf("latestBackup() = %s, want %s", key, want)
+	}
+}
+
+func TestCheckDefaultQueryOutput(t *testing.T) {
+	tests := []struct {
+		output  string
+		wantErr bool
+	}{
+		{output: "3\n"},
+		{output: "Current Mongosh Log ID: 1234\n2\n"},
+		{output: "0\n", wantErr: true},
+		{output: "", wantErr: true},
+		{output: "ERR unknown command\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := checkDefaultQueryOutput(tt.output); (err != nil) != tt.wantErr {
+			t.Errorf("checkDefaultQueryOutput(%q) error = %v, wantErr %v", tt.output, err, tt.wantErr)
+		}
+	}
+}
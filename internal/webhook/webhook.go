@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"label-backup/internal/logger"
+	"label-backup/internal/metrics"
 	"label-backup/internal/model"
 
 	"go.uber.org/zap"
@@ -54,6 +55,12 @@ func NewCircuitBreaker(failureThreshold int, recoveryTimeout time.Duration) *Cir
 	}
 }
 
+// setState records a transition; the caller must hold cb.mu.
+func (cb *CircuitBreaker) setState(state CircuitBreakerState) {
+	cb.state = state
+	metrics.WebhookCircuitBreakerState.Set(float64(state))
+}
+
 func (cb *CircuitBreaker) Call(fn func() error) error {
 	cb.mu.RLock()
 	state := cb.state
@@ -63,7 +70,7 @@ func (cb *CircuitBreaker) Call(fn func() error) error {
 	case CircuitOpen:
 		cb.mu.Lock()
 		if time.Since(cb.lastFailureTime) >= cb.recoveryTimeout {
-			cb.state = CircuitHalfOpen
+			cb.setState(CircuitHalfOpen)
 			cb.mu.Unlock()
 			logger.Log.Info("Circuit breaker transitioning to half-open state")
 		} else {
@@ -77,12 +84,12 @@ func (cb *CircuitBreaker) Call(fn func() error) error {
 		if err != nil {
 			cb.failureCount++
 			cb.lastFailureTime = time.Now()
-			cb.state = CircuitOpen
+			cb.setState(CircuitOpen)
 			cb.mu.Unlock()
 			logger.Log.Warn("Circuit breaker call failed, transitioning to open state", zap.Error(err))
 			return err
 		}
-		cb.state = CircuitClosed
+		cb.setState(CircuitClosed)
 		cb.failureCount = 0
 		cb.mu.Unlock()
 		logger.Log.Info("Circuit breaker call succeeded, transitioning to closed state")
@@ -94,7 +101,7 @@ func (cb *CircuitBreaker) Call(fn func() error) error {
 			cb.failureCount++
 			cb.lastFailureTime = time.Now()
 			if cb.failureCount >= cb.failureThreshold {
-				cb.state = CircuitOpen
+				cb.setState(CircuitOpen)
 				logger.Log.Warn("Circuit breaker failure threshold reached, transitioning to open state", 
 					zap.Int("failureCount", cb.failureCount),
 					zap.Int("threshold", cb.failureThreshold),
@@ -114,20 +121,33 @@ type WebhookSender interface {
 	Stop()
 }
 
+const (
+	EventBackup        = "backup"
+	EventBackupSkipped = "backup_skipped"
+	EventVerify        = "verify"
+	EventGCSafeguard   = "gc_safeguard"
+)
+
 type NotificationPayload struct {
-	ContainerID     string  `json:"container_id"`
-	ContainerName   string  `json:"container_name"`
-	DatabaseType    string  `json:"database_type"`
-	DatabaseName    string  `json:"database_name,omitempty"`
-	DestinationURL  string  `json:"destination_url"`
-	Success         bool    `json:"success"`
-	Error           string  `json:"error,omitempty"`
-	BackupSize      int64   `json:"backup_size_bytes,omitempty"`
-	DurationSeconds float64 `json:"duration_seconds"`
-	Timestamp       string  `json:"timestamp_utc"`
-	CronSchedule    string  `json:"cron_schedule,omitempty"`
-	BackupPrefix    string  `json:"backup_prefix,omitempty"`
-	DestinationType string  `json:"destination_type,omitempty"`
+	Event           string             `json:"event,omitempty"`
+	ContainerID     string             `json:"container_id"`
+	ContainerName   string             `json:"container_name"`
+	DatabaseType    string             `json:"database_type"`
+	DatabaseName    string             `json:"database_name,omitempty"`
+	DestinationURL  string             `json:"destination_url"`
+	Success         bool               `json:"success"`
+	Error           string             `json:"error,omitempty"`
+	BackupSize      int64              `json:"backup_size_bytes,omitempty"`
+	DurationSeconds float64            `json:"duration_seconds"`
+	Timestamp       string             `json:"timestamp_utc"`
+	CronSchedule    string             `json:"cron_schedule,omitempty"`
+	BackupPrefix    string             `json:"backup_prefix,omitempty"`
+	DestinationType string             `json:"destination_type,omitempty"`
+	ObjectName      string             `json:"object_name,omitempty"`
+	Warning         string             `json:"warning,omitempty"`
+	Attempts        int                `json:"attempts,omitempty"`
+	Hooks           []model.HookResult `json:"hooks,omitempty"`
+	Objects         []string           `json:"objects,omitempty"`
 }
 
 type workItem struct {
@@ -288,10 +308,12 @@ func (s *Sender) sendWithRetries(payload NotificationPayload, targetURL, secretK
 	for attempt := 0; attempt <= s.maxRetries; attempt++ {
 		currentAttemptFields := append(baseLogFields, zap.Int("attempt", attempt+1), zap.Int("maxAttempts", s.maxRetries+1), zap.String("targetHost", targetHost))
 			lastErr = s.sendAttempt(payload, targetURL, secretKey, targetHost)
+			metrics.WebhookAttempts.WithLabelValues(targetHost).Inc()
 		if lastErr == nil {
 			logger.Log.Info("Webhook sent successfully", currentAttemptFields...)
 				return nil
 		}
+		metrics.WebhookFailures.WithLabelValues(targetHost).Inc()
 		logger.Log.Warn("Webhook send attempt failed", append(currentAttemptFields, zap.Error(lastErr))...)
 		if attempt < s.maxRetries {
 				backoffDuration := time.Duration(2<<attempt) * time.Second
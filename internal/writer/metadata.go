@@ -13,24 +13,91 @@ import (
 	"go.uber.org/zap"
 )
 
+// MetadataSuffix is appended to a backup's object name to form its metadata sidecar.
+const MetadataSuffix = ".metadata.json"
+
 type BackupMetadata struct {
-	Timestamp       time.Time `json:"timestamp"`
-	ContainerID     string    `json:"container_id"`
-	ContainerName   string    `json:"container_name"`
-	DatabaseType    string    `json:"database_type"`
-	DatabaseName    string    `json:"database_name,omitempty"`
-	BackupSize      int64     `json:"backup_size_bytes"`
-	Checksum        string    `json:"checksum,omitempty"`
-	CompressionType string    `json:"compression_type"`
-	Version         string    `json:"version"`
-	Destination     string    `json:"destination"`
-	DurationSeconds float64   `json:"duration_seconds"`
-	Success         bool      `json:"success"`
-	Error           string    `json:"error,omitempty"`
+	Timestamp       time.Time       `json:"timestamp"`
+	ContainerID     string          `json:"container_id"`
+	ContainerName   string          `json:"container_name"`
+	DatabaseType    string          `json:"database_type"`
+	DatabaseName    string          `json:"database_name,omitempty"`
+	BackupSize      int64           `json:"backup_size_bytes"`
+	Checksum        string          `json:"checksum,omitempty"`
+	CompressionType string          `json:"compression_type"`
+	Encrypted       bool            `json:"encrypted,omitempty"`
+	EncryptionType  string          `json:"encryption_type,omitempty"`
+	EncryptionKey   string          `json:"encryption_key,omitempty"`
+	Version         string          `json:"version"`
+	Destination     string          `json:"destination"`
+	DurationSeconds float64         `json:"duration_seconds"`
+	Success         bool            `json:"success"`
+	Error           string          `json:"error,omitempty"`
+	VerifiedAt      *time.Time      `json:"verified_at,omitempty"`
+	VerifyStatus    string          `json:"verify_status,omitempty"`
+	VerifyError     string          `json:"verify_error,omitempty"`
+	Part            string          `json:"part,omitempty"`
+	Manifest        *Manifest       `json:"manifest,omitempty"`
+	Binlog          *BinlogPosition `json:"binlog,omitempty"`
+	WAL             *WALPosition    `json:"wal,omitempty"`
+}
+
+// BinlogPosition is the point in the MySQL binary log a full dump is consistent
+// with. Replaying the binary logs archived below Archive from there recovers the
+// database to a later point in time.
+type BinlogPosition struct {
+	File     string `json:"file"`
+	Position int64  `json:"position"`
+	// Database is the database the dump covers, empty when it covers all of them
+	Database string `json:"database,omitempty"`
+	Archive  string `json:"archive,omitempty"`
+}
+
+// WALPosition is where in the PostgreSQL write-ahead log a base backup starts.
+// Restoring it needs the WAL segments archived below Archive from StartSegment on,
+// so retention keeps those as long as the base backup.
+type WALPosition struct {
+	StartLSN     string `json:"start_lsn"`
+	StartSegment string `json:"start_segment"`
+	Archive      string `json:"archive,omitempty"`
+}
+
+// Manifest ties together the objects of a backup run that was split into several
+// objects. Every object's metadata carries the same manifest, which also lists
+// the parts that failed and have no object.
+type Manifest struct {
+	RunID   string          `json:"run_id"`
+	Objects []ManifestEntry `json:"objects"`
+}
+
+const (
+	ManifestStatusSucceeded = "succeeded"
+	ManifestStatusFailed    = "failed"
+)
+
+type ManifestEntry struct {
+	Object   string `json:"object,omitempty"`
+	Part     string `json:"part"`
+	Kind     string `json:"kind"`
+	Database string `json:"database,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Size     int64  `json:"size_bytes,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// Complete reports whether every part of the run succeeded.
+func (m *Manifest) Complete() bool {
+	for _, entry := range m.Objects {
+		if entry.Status == ManifestStatusFailed {
+			return false
+		}
+	}
+	return true
 }
 
 func WriteMetadata(ctx context.Context, writer BackupWriter, metadata BackupMetadata, objectName string) error {
-	metadataName := objectName + ".metadata.json"
+	metadataName := objectName + MetadataSuffix
 	
 	jsonData, err := json.MarshalIndent(metadata, "", "  ")
 	if err != nil {
@@ -53,7 +120,7 @@ func WriteMetadata(ctx context.Context, writer BackupWriter, metadata BackupMeta
 }
 
 func ReadMetadata(ctx context.Context, writer BackupWriter, objectName string) (*BackupMetadata, error) {
-	metadataName := objectName + ".metadata.json"
+	metadataName := objectName + MetadataSuffix
 	
 	logger.Log.Debug("Reading backup metadata",
 		zap.String("metadataFile", metadataName),
@@ -5,6 +5,9 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -78,10 +81,170 @@ func GetWriter(spec model.BackupSpec, globalConfig map[string]string) (BackupWri
 	return factory(spec, globalConfig)
 }
 
+const objectTimestampLayout = "20060102150405"
+
+// Directories below the prefix that hold logs archived next to the backups
+const (
+	binlogDir = "binlog"
+	walDir    = "wal"
+)
+
+var walSegmentPattern = regexp.MustCompile(`^[0-9A-F]{24}$`)
+
+// The optional group is the part name of a backup split into several objects
+var objectTimestampPattern = regexp.MustCompile(`-(\d{14})(?:\.([A-Za-z0-9_.-]+?))?\.dump\.gz`)
+
+// objectSuffixPattern matches what follows ObjectKeyPrefix in the keys of a spec
+var objectSuffixPattern = regexp.MustCompile(`^\d{14}(?:\.[A-Za-z0-9_.-]+?)?\.dump\.gz(?:\.gpg|\.age)?(?:\.metadata\.json)?$`)
+
 func GenerateObjectName(spec model.BackupSpec) string {
-	timestamp := time.Now().UTC().Format("20060102150405")
+	timestamp := time.Now().UTC().Format(objectTimestampLayout)
+	return ObjectKeyPrefix(spec) + timestamp + ".dump.gz"
+}
+
+// ParseObjectTimestamp extracts the backup time that GenerateObjectName encoded in a key.
+func ParseObjectTimestamp(key string) (time.Time, bool) {
+	match := objectTimestampPattern.FindStringSubmatch(key)
+	if match == nil {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(objectTimestampLayout, match[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
+
+// PartObjectName names one part of a backup split into several objects, e.g. a
+// single database of a cluster backup, after the run's objectName.
+func PartObjectName(objectName, part string) string {
+	i := strings.LastIndex(objectName, ".dump.gz")
+	if i == -1 {
+		return objectName + "." + SanitizeKeyPart(part)
+	}
+	return objectName[:i] + "." + SanitizeKeyPart(part) + objectName[i:]
+}
+
+// ObjectPart returns the part name that PartObjectName put in key, or "" for a
+// backup stored as a single object.
+func ObjectPart(key string) string {
+	match := objectTimestampPattern.FindStringSubmatch(key)
+	if match == nil {
+		return ""
+	}
+	return match[2]
+}
+
+// BackupRunKey returns key up to its timestamp, which every object of one backup
+// run shares. Keys without a timestamp are returned as is.
+func BackupRunKey(key string) string {
+	loc := objectTimestampPattern.FindStringSubmatchIndex(key)
+	if loc == nil {
+		return key
+	}
+	return key[:loc[3]]
+}
+
+// SanitizeKeyPart replaces characters that are not safe in object keys with '_'.
+func SanitizeKeyPart(s string) string {
+	s = strings.ReplaceAll(s, ":", "_")
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// ObjectKeyPrefix returns the part of the object name shared by every backup of a spec,
+// i.e. everything up to the timestamp.
+func ObjectKeyPrefix(spec model.BackupSpec) string {
+	fileName := objectBaseName(spec) + "-"
+
+	if spec.Prefix != "" {
+		return fmt.Sprintf("%s/%s", strings.Trim(spec.Prefix, "/"), fileName)
+	}
+	return fileName
+}
+
+// IsObjectOf reports whether key is a backup, backup part or metadata object of
+// spec. Unlike a plain ObjectKeyPrefix match it does not take the backups of a
+// database whose name merely starts with the spec's, e.g. "app-staging" for "app".
+func IsObjectOf(spec model.BackupSpec, key string) bool {
+	rest, ok := strings.CutPrefix(key, ObjectKeyPrefix(spec))
+	return ok && objectSuffixPattern.MatchString(rest)
+}
+
+// BinlogKeyPrefix returns the directory the binary logs archived for spec are
+// stored in. It is next to the backups but outside of ObjectKeyPrefix, so they
+// are never taken for backups of the spec.
+func BinlogKeyPrefix(spec model.BackupSpec) string {
+	return archiveKeyPrefix(spec, binlogDir)
+}
+
+// WALKeyPrefix is BinlogKeyPrefix for the PostgreSQL WAL segments archived for
+// a postgres-physical spec.
+func WALKeyPrefix(spec model.BackupSpec) string {
+	return archiveKeyPrefix(spec, walDir)
+}
+
+// IsWALKey reports whether key is below the WALKeyPrefix of any spec. Such
+// objects belong to base backups rather than being backups themselves.
+func IsWALKey(key string) bool {
+	return path.Base(path.Dir(path.Dir(key))) == walDir
+}
+
+// IsBinlogKey is IsWALKey for the binary logs below BinlogKeyPrefix.
+func IsBinlogKey(key string) bool {
+	return path.Base(path.Dir(path.Dir(key))) == binlogDir
+}
+
+// SplitBinlogName splits a binary log file name such as mysql-bin.000042 into its
+// base name and sequence number.
+func SplitBinlogName(name string) (string, int, bool) {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.Atoi(name[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return name[:i], seq, true
+}
+
+// IsWALSegment reports whether name is the file name of a WAL segment: the
+// timeline, log and segment number as 24 hex digits.
+func IsWALSegment(name string) bool {
+	return walSegmentPattern.MatchString(name)
+}
+
+func archiveKeyPrefix(spec model.BackupSpec, kind string) string {
+	dir := kind + "/" + objectBaseName(spec) + "/"
+	if spec.Prefix != "" {
+		return strings.Trim(spec.Prefix, "/") + "/" + dir
+	}
+	return dir
+}
+
+// LogFileName returns the log file name of an object below BinlogKeyPrefix or
+// WALKeyPrefix, without the compression and encryption extensions.
+func LogFileName(key string) string {
+	name := path.Base(key)
+	if i := strings.Index(name, ".gz"); i != -1 {
+		return name[:i]
+	}
+	return name
+}
+
+// objectBaseName names the backups of a spec: the type and the database.
+func objectBaseName(spec model.BackupSpec) string {
 	var dbNamePart string
-	if spec.Database != "" {
+	if spec.MultiDatabase() {
+		dbNamePart = "cluster"
+	} else if spec.MySQLAllDatabases {
+		dbNamePart = "all"
+	} else if spec.Database != "" {
 		dbNamePart = spec.Database
 	} else {
 		lastSlash := strings.LastIndex(spec.Conn, "/")
@@ -96,20 +259,9 @@ func GenerateObjectName(spec model.BackupSpec) string {
 			dbNamePart = "database"
 		}
 	}
-	dbNamePart = strings.ReplaceAll(dbNamePart, ":", "_") 
-	dbNamePart = strings.Map(func(r rune) rune {
-        if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
-            return r
-        }
-        return '_'
-    }, dbNamePart)
+	dbNamePart = SanitizeKeyPart(dbNamePart)
 
-	fileName := fmt.Sprintf("%s-%s-%s.dump.gz", spec.Type, dbNamePart, timestamp)
-
-	if spec.Prefix != "" {
-		return fmt.Sprintf("%s/%s", strings.Trim(spec.Prefix, "/"), fileName)
-	}
-	return fileName
+	return fmt.Sprintf("%s-%s", spec.Type, dbNamePart)
 }
 
 func ValidateBackup(ctx context.Context, reader io.Reader) (string, error) {
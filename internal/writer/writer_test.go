@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"label-backup/internal/model"
 )
@@ -92,3 +93,116 @@ func TestValidateBackup(t *testing.T) {
 		})
 	}
 }
+
+func TestParseObjectTimestamp(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "plain backup",
+			key:    "db/postgres-app-20250314020500.dump.gz",
+			want:   time.Date(2025, 3, 14, 2, 5, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "encrypted backup sidecar",
+			key:    "postgres-app-20250314020500.dump.gz.age.metadata.json",
+			want:   time.Date(2025, 3, 14, 2, 5, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "no timestamp",
+			key:    "db/notes.txt",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseObjectTimestamp(tt.key)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("ParseObjectTimestamp(%q) = %v, %v, want %v, %v", tt.key, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPartObjectName(t *testing.T) {
+	key := PartObjectName("db/postgres-cluster-20250314020500.dump.gz.age", "my app")
+	if want := "db/postgres-cluster-20250314020500.my_app.dump.gz.age"; key != want {
+		t.Fatalf("PartObjectName() = %s, want %s", key, want)
+	}
+	if part := ObjectPart(key + MetadataSuffix); part != "my_app" {
+		t.Errorf("ObjectPart() = %q, want my_app", part)
+	}
+	if runKey := BackupRunKey(key); runKey != "db/postgres-cluster-20250314020500" {
+		t.Errorf("BackupRunKey() = %s", runKey)
+	}
+	if ts, ok := ParseObjectTimestamp(key); !ok || !ts.Equal(time.Date(2025, 3, 14, 2, 5, 0, 0, time.UTC)) {
+		t.Errorf("ParseObjectTimestamp() = %v, %v", ts, ok)
+	}
+	if part := ObjectPart("db/postgres-app-20250314020500.dump.gz"); part != "" {
+		t.Errorf("ObjectPart() of a single-object backup = %q, want empty", part)
+	}
+}
+
+func TestIsObjectOf(t *testing.T) {
+	spec := model.BackupSpec{Type: "postgres", Database: "app", Prefix: "db"}
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"db/postgres-app-20250314020500.dump.gz", true},
+		{"db/postgres-app-20250314020500.dump.gz.age", true},
+		{"db/postgres-app-20250314020500.dump.gz.gpg.metadata.json", true},
+		{"db/postgres-app-20250314020500.globals.dump.gz", true},
+		{"db/postgres-app-staging-20250314020500.dump.gz", false},
+		{"db/postgres-app-20250314020500.dump.gz.tmp", false},
+		{"db/binlog/postgres-app/000001.gz", false},
+	}
+	for _, tt := range tests {
+		if got := IsObjectOf(spec, tt.key); got != tt.want {
+			t.Errorf("IsObjectOf(%s) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestBinlogKeyPrefix(t *testing.T) {
+	spec := model.BackupSpec{Type: "mysql", Conn: "mysql://root@db:3306/shop", Prefix: "/prod/"}
+	prefix := BinlogKeyPrefix(spec)
+	if want := "prod/binlog/mysql-shop/"; prefix != want {
+		t.Fatalf("BinlogKeyPrefix() = %s, want %s", prefix, want)
+	}
+	if strings.HasPrefix(prefix, ObjectKeyPrefix(spec)) {
+		t.Errorf("BinlogKeyPrefix() %s is below the backup prefix %s", prefix, ObjectKeyPrefix(spec))
+	}
+	if name := LogFileName(prefix + "mysql-bin.000012.gz.age"); name != "mysql-bin.000012" {
+		t.Errorf("LogFileName() = %s, want mysql-bin.000012", name)
+	}
+	if !IsBinlogKey(prefix+"mysql-bin.000012.gz") || IsBinlogKey(ObjectKeyPrefix(spec)+"20250314020000.dump.gz") {
+		t.Error("IsBinlogKey() does not tell binary logs from dumps")
+	}
+	if base, seq, ok := SplitBinlogName("mysql-bin.000012"); !ok || base != "mysql-bin" || seq != 12 {
+		t.Errorf("SplitBinlogName() = %s, %d, %v", base, seq, ok)
+	}
+}
+
+func TestWALKeyPrefix(t *testing.T) {
+	spec := model.BackupSpec{Type: "postgres-physical", Conn: "postgresql://backup@db:5432/postgres", Prefix: "pg"}
+	prefix := WALKeyPrefix(spec)
+	if want := "pg/wal/postgres-physical-postgres/"; prefix != want {
+		t.Fatalf("WALKeyPrefix() = %s, want %s", prefix, want)
+	}
+	if !IsWALKey(prefix + "000000010000000000000002.gz") {
+		t.Error("IsWALKey() = false for a WAL segment")
+	}
+	if IsWALKey(ObjectKeyPrefix(spec) + "20250314020000.dump.gz") {
+		t.Error("IsWALKey() = true for a base backup")
+	}
+	if !IsWALSegment("000000010000000000000002") || IsWALSegment("00000002.history") || IsWALSegment("000000010000000000000002.partial") {
+		t.Error("IsWALSegment() does not tell segments from other WAL files")
+	}
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"strconv"
 	"strings"
@@ -10,17 +13,25 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"label-backup/internal/archiver"
+	"label-backup/internal/catalog"
 	"label-backup/internal/discovery"
+	"label-backup/internal/dumper"
+	"label-backup/internal/encryption"
 	"label-backup/internal/gc"
+	"label-backup/internal/jobstore"
 	"label-backup/internal/logger"
 	"label-backup/internal/model"
+	"label-backup/internal/restorer"
 	"label-backup/internal/scheduler"
 	"label-backup/internal/webhook"
 	"label-backup/internal/writer"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
@@ -31,10 +42,25 @@ const (
 	EnvGlobalRetentionPeriod      = "GLOBAL_RETENTION_PERIOD"
 	DefaultGlobalRetentionPeriod  = "7d" 
 	EnvGCDryRun                   = "GC_DRY_RUN"
+	EnvGCKeepSuccessful           = "GC_KEEP_SUCCESSFUL"
+	EnvGCSweepOrphans             = "GC_SWEEP_ORPHANS"
+	EnvRestoreAPIToken            = "RESTORE_API_TOKEN"
+	EnvAPIToken                   = "API_TOKEN"
+	EnvStateDir                   = "STATE_DIR"
+	EnvJobHistoryMaxAge           = "JOB_HISTORY_MAX_AGE"
+	DefaultJobHistoryMaxAge       = "30d"
+	EnvJobHistoryMaxEntries       = "JOB_HISTORY_MAX_ENTRIES"
 )
 
 var globalRetentionPeriod time.Duration 
 var gcDryRun bool                     
+var gcKeepSuccessful int
+var gcSweepOrphans bool
+var restoreAPIToken string
+var apiToken string
+var stateDir string
+var jobHistoryMaxAge time.Duration
+var jobHistoryMaxEntries int
 
 func parseRetentionPeriod(retentionStr string, defaultValue string) time.Duration {
 	value := strings.TrimSpace(retentionStr)
@@ -132,6 +158,57 @@ func loadGlobalConfig() map[string]string {
 		logger.Log.Info("LOCAL_BACKUP_PATH not set, using default", zap.String("path", writer.DefaultLocalPath))
 	}
 
+	if gpgKeyPath := getTrimmedEnv(encryption.GlobalConfigKeyGPGPublicKeyPath); gpgKeyPath != "" {
+		cfg[encryption.GlobalConfigKeyGPGPublicKeyPath] = gpgKeyPath
+		logger.Log.Info("Using GPG public key from env", zap.String("path", gpgKeyPath))
+	}
+	if ageRecipients := getTrimmedEnv(encryption.GlobalConfigKeyAgeRecipients); ageRecipients != "" {
+		cfg[encryption.GlobalConfigKeyAgeRecipients] = ageRecipients
+		logger.Log.Info("Using age recipients from env", zap.Int("count", len(encryption.ParseRecipientList(ageRecipients))))
+	}
+	if agePassphraseFile := getTrimmedEnv(encryption.GlobalConfigKeyAgePassphraseFile); agePassphraseFile != "" {
+		cfg[encryption.GlobalConfigKeyAgePassphraseFile] = agePassphraseFile
+		logger.Log.Info("Using age passphrase file from env", zap.String("path", agePassphraseFile))
+	}
+	if ageIdentityFile := getTrimmedEnv(encryption.GlobalConfigKeyAgeIdentityFile); ageIdentityFile != "" {
+		cfg[encryption.GlobalConfigKeyAgeIdentityFile] = ageIdentityFile
+		logger.Log.Info("Using age identity file from env", zap.String("path", ageIdentityFile))
+	}
+	if verifyTimeout := getTrimmedEnv("VERIFY_TIMEOUT_MINUTES"); verifyTimeout != "" {
+		cfg["VERIFY_TIMEOUT_MINUTES"] = verifyTimeout
+		logger.Log.Info("Using restore drill timeout from env", zap.String("minutes", verifyTimeout))
+	}
+	for _, key := range []string{scheduler.GlobalConfigKeyRetryMax, scheduler.GlobalConfigKeyRetryBackoff, scheduler.GlobalConfigKeyRetryMaxBackoff} {
+		if value := getTrimmedEnv(key); value != "" {
+			cfg[key] = value
+			logger.Log.Info("Using backup retry setting from env", zap.String("key", key), zap.String("value", value))
+		}
+	}
+	if hookTimeout := getTrimmedEnv(scheduler.GlobalConfigKeyHookTimeout); hookTimeout != "" {
+		cfg[scheduler.GlobalConfigKeyHookTimeout] = hookTimeout
+		logger.Log.Info("Using backup hook timeout from env", zap.String("timeout", hookTimeout))
+	}
+	for _, key := range []string{scheduler.GlobalConfigKeyQueueMaxDepth, scheduler.GlobalConfigKeyQueueMaxWait} {
+		if value := getTrimmedEnv(key); value != "" {
+			cfg[key] = value
+			logger.Log.Info("Using backup queue setting from env", zap.String("key", key), zap.String("value", value))
+		}
+	}
+	for _, key := range []string{scheduler.GlobalConfigKeyDestLimit, scheduler.GlobalConfigKeyDestLimits, scheduler.GlobalConfigKeyHostLimit, scheduler.GlobalConfigKeyHostLimits} {
+		if value := getTrimmedEnv(key); value != "" {
+			cfg[key] = value
+			logger.Log.Info("Using concurrency limit from env", zap.String("key", key), zap.String("value", value))
+		}
+	}
+	if scratchDir := getTrimmedEnv(dumper.GlobalConfigKeyScratchDir); scratchDir != "" {
+		cfg[dumper.GlobalConfigKeyScratchDir] = scratchDir
+		logger.Log.Info("Using dump scratch directory from env", zap.String("path", scratchDir))
+	}
+	if uploadInterval := getTrimmedEnv(archiver.GlobalConfigKeyUploadInterval); uploadInterval != "" {
+		cfg[archiver.GlobalConfigKeyUploadInterval] = uploadInterval
+		logger.Log.Info("Using binary log upload interval from env", zap.String("interval", uploadInterval))
+	}
+
 	retentionPeriodStr := os.Getenv(EnvGlobalRetentionPeriod)
 	globalRetentionPeriod = parseRetentionPeriod(retentionPeriodStr, DefaultGlobalRetentionPeriod)
 	logger.Log.Info("Using global retention period", zap.Duration("period", globalRetentionPeriod))
@@ -140,10 +217,85 @@ func loadGlobalConfig() map[string]string {
 	gcDryRun = (dryRunStr == "true" || dryRunStr == "1")
 	logger.Log.Info("GC Dry Run mode", zap.Bool("enabled", gcDryRun))
 
+	gcKeepSuccessful = gc.DefaultKeepSuccessful
+	if keepStr := getTrimmedEnv(EnvGCKeepSuccessful); keepStr != "" {
+		if keep, err := strconv.Atoi(keepStr); err == nil && keep >= 0 {
+			gcKeepSuccessful = keep
+		} else {
+			logger.Log.Warn("Invalid GC_KEEP_SUCCESSFUL value, using default",
+				zap.String("value", keepStr),
+				zap.Int("default", gc.DefaultKeepSuccessful),
+			)
+		}
+	}
+	logger.Log.Info("GC last-good-backup safeguard", zap.Int("keepSuccessful", gcKeepSuccessful))
+
+	sweepStr := strings.ToLower(getTrimmedEnv(EnvGCSweepOrphans))
+	gcSweepOrphans = (sweepStr == "true" || sweepStr == "1")
+	logger.Log.Info("GC orphan sweep", zap.Bool("enabled", gcSweepOrphans))
+
+	restoreAPIToken = getTrimmedEnv(EnvRestoreAPIToken)
+	logger.Log.Info("Restore API", zap.Bool("enabled", restoreAPIToken != ""))
+
+	apiToken = getTrimmedEnv(EnvAPIToken)
+	logger.Log.Info("Backup trigger API", zap.Bool("enabled", apiToken != ""))
+
+	stateDir = getTrimmedEnv(EnvStateDir)
+	jobHistoryMaxAge = parseRetentionPeriod(getTrimmedEnv(EnvJobHistoryMaxAge), DefaultJobHistoryMaxAge)
+	jobHistoryMaxEntries = jobstore.DefaultMaxEntries
+	if maxStr := getTrimmedEnv(EnvJobHistoryMaxEntries); maxStr != "" {
+		if maxEntries, err := strconv.Atoi(maxStr); err == nil && maxEntries > 0 {
+			jobHistoryMaxEntries = maxEntries
+		} else {
+			logger.Log.Warn("Invalid JOB_HISTORY_MAX_ENTRIES value, using default",
+				zap.String("value", maxStr),
+				zap.Int("default", jobstore.DefaultMaxEntries),
+			)
+		}
+	}
+	logger.Log.Info("Job history", zap.Bool("enabled", stateDir != ""), zap.String("stateDir", stateDir))
+
 	return cfg
 }
 
-func runGlobalGC(ctx context.Context, discoveryWatcher *discovery.Watcher, writerCfg map[string]string, retentionPeriodForGC time.Duration, isDryRun bool) {
+func runRestoreCommand(args []string) int {
+	defer logger.Close()
+
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	var req restorer.Request
+	fs.StringVar(&req.Object, "object", "", "object key of the backup to restore (required)")
+	fs.StringVar(&req.Target, "target", "", "connection string of the database to restore into; for redis, the path of the RDB file (required)")
+	fs.StringVar(&req.Dest, "dest", "local", "where the backup is stored: local or remote")
+	fs.StringVar(&req.Type, "type", "", "database type, defaults to the type recorded in the backup metadata")
+	fs.StringVar(&req.Database, "database", "", "database name, overrides the one in the target connection string")
+	fs.StringVar(&req.PointInTime, "until", "", "RFC 3339 time to roll a mysql backup forward to with the archived binary logs")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if req.Object == "" || req.Target == "" {
+		fmt.Fprintln(os.Stderr, "usage: label-backup restore -object <key> -target <connection> [-dest local|remote] [-type <db>] [-database <name>] [-until <time>]")
+		return 2
+	}
+
+	cfg := loadGlobalConfig()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	result, err := restorer.RestoreObject(ctx, req, cfg)
+	if err != nil {
+		logger.Log.Error("Restore failed", zap.String("object", req.Object), zap.Error(err))
+		return 1
+	}
+	logger.Log.Info("Restore finished",
+		zap.String("object", result.Object),
+		zap.String("databaseType", result.DatabaseType),
+		zap.Float64("durationSeconds", result.DurationSeconds),
+	)
+	return 0
+}
+
+func runGlobalGC(ctx context.Context, discoveryWatcher *discovery.Watcher, writerCfg map[string]string, retentionPeriodForGC time.Duration, isDryRun bool, keepSuccessful int, sweepOrphans bool, webhookSender webhook.WebhookSender) {
 	logger.Log.Info("Starting nightly global Garbage Collection run...")
 	activeSpecs := discoveryWatcher.GetRegistry() 
 
@@ -169,7 +321,7 @@ func runGlobalGC(ctx context.Context, discoveryWatcher *discovery.Watcher, write
 			continue
 		}
 
-		gcRunner, err := gc.NewRunner(spec, backupWriter, retentionPeriodForGC, isDryRun)
+		gcRunner, err := gc.NewRunner(spec, backupWriter, retentionPeriodForGC, isDryRun, keepSuccessful, webhookSender)
 		if err != nil {
 			logger.Log.Error("Global GC: Failed to create GC runner for spec", zap.String("containerID", containerID), zap.Error(err))
 			continue
@@ -182,11 +334,47 @@ func runGlobalGC(ctx context.Context, discoveryWatcher *discovery.Watcher, write
 			    zap.Error(err),
 			)
 		}
+
+		if sweepOrphans {
+			if err := gcRunner.SweepOrphans(ctx); err != nil {
+				logger.Log.Error("Global GC: Error during orphan sweep for spec",
+					zap.String("containerID", containerID),
+					zap.String("prefix", spec.Prefix),
+					zap.Error(err),
+				)
+			}
+		}
 	}
 	logger.Log.Info("Nightly global Garbage Collection run finished.")
 }
 
 
+// parseCatalogTime accepts RFC3339 timestamps or plain dates (UTC midnight).
+func parseCatalogTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// authorizeAPI checks the bearer token for a mutating endpoint. The endpoint is
+// disabled (403) while its token is unset.
+func authorizeAPI(w http.ResponseWriter, r *http.Request, expected string, envName string) bool {
+	if expected == "" {
+		http.Error(w, fmt.Sprintf("this endpoint is disabled, set %s to enable it", envName), http.StatusForbidden)
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
 func checkDiskSpace(path string) error {
 	return writer.CheckDiskSpace(path)
 }
@@ -212,6 +400,38 @@ func validateConfig(globalConfig map[string]string) error {
 		}
 	}
 
+	if retryMax, ok := globalConfig[scheduler.GlobalConfigKeyRetryMax]; ok && retryMax != "" {
+		if n, err := strconv.Atoi(retryMax); err != nil || n < 0 {
+			errors = append(errors, fmt.Sprintf("Invalid %s '%s': must be a non-negative integer", scheduler.GlobalConfigKeyRetryMax, retryMax))
+		}
+	}
+	if depth, ok := globalConfig[scheduler.GlobalConfigKeyQueueMaxDepth]; ok && depth != "" {
+		if n, err := strconv.Atoi(depth); err != nil || n < 0 {
+			errors = append(errors, fmt.Sprintf("Invalid %s '%s': must be a non-negative integer", scheduler.GlobalConfigKeyQueueMaxDepth, depth))
+		}
+	}
+	for _, key := range []string{scheduler.GlobalConfigKeyDestLimit, scheduler.GlobalConfigKeyHostLimit} {
+		if value, ok := globalConfig[key]; ok && value != "" {
+			if n, err := strconv.Atoi(value); err != nil || n < 0 {
+				errors = append(errors, fmt.Sprintf("Invalid %s '%s': must be a non-negative integer", key, value))
+			}
+		}
+	}
+	for _, key := range []string{scheduler.GlobalConfigKeyDestLimits, scheduler.GlobalConfigKeyHostLimits} {
+		if value, ok := globalConfig[key]; ok && value != "" {
+			if _, err := scheduler.ParseLimitList(value); err != nil {
+				errors = append(errors, fmt.Sprintf("Invalid %s '%s': %v", key, value, err))
+			}
+		}
+	}
+	for _, key := range []string{scheduler.GlobalConfigKeyRetryBackoff, scheduler.GlobalConfigKeyRetryMaxBackoff, scheduler.GlobalConfigKeyQueueMaxWait, scheduler.GlobalConfigKeyHookTimeout, archiver.GlobalConfigKeyUploadInterval} {
+		if value, ok := globalConfig[key]; ok && value != "" {
+			if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+				errors = append(errors, fmt.Sprintf("Invalid %s '%s': must be a positive duration such as 30s or 5m", key, value))
+			}
+		}
+	}
+
 	if bucket, ok := globalConfig["BUCKET_NAME"]; ok && bucket != "" {
 		logger.Log.Debug("S3 bucket configuration validated", zap.String("bucket", bucket))
 	}
@@ -229,6 +449,10 @@ func validateConfig(globalConfig map[string]string) error {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		os.Exit(runRestoreCommand(os.Args[2:]))
+	}
+
 	logger.Log.Info("Label Backup Agent starting...")
 	defer logger.Close() 
 
@@ -246,16 +470,31 @@ func main() {
 		logger.Log.Fatal("Failed to initialize discovery watcher", zap.Error(err))
 	}
 	defer discoveryWatcher.Close() 
+	// backup.mode=exec runs dump tools through the watcher's Docker client
+	dumper.SetDockerClient(discoveryWatcher.DockerClient())
+	dumper.SetScratchDir(globalCfgForWriterAndOthers[dumper.GlobalConfigKeyScratchDir])
 
 	webhookSender := webhook.NewSender(globalCfgForWriterAndOthers) 
 
-	sched := scheduler.NewScheduler(globalCfgForWriterAndOthers, webhookSender, discoveryWatcher) 
+	// The history file is opened once; STATE_DIR changes need a restart
+	var jobHistory *jobstore.Store
+	if stateDir != "" {
+		jobHistory, err = jobstore.Open(stateDir, jobHistoryMaxAge, jobHistoryMaxEntries)
+		if err != nil {
+			logger.Log.Fatal("Failed to open job history store", zap.Error(err))
+		}
+		defer jobHistory.Close()
+	}
+
+	// Replaced on SIGHUP while the API handlers read it
+	var sched atomic.Pointer[scheduler.Scheduler]
+	sched.Store(scheduler.NewScheduler(globalCfgForWriterAndOthers, webhookSender, discoveryWatcher, jobHistory))
 
 	gcCron := cron.New(cron.WithLogger(logger.NewCronZapLogger(logger.Log.Named("gc-cron")))) 
 	_, err = gcCron.AddFunc("0 4 * * *", func() { 
 		gcCtx, gcCancel := context.WithTimeout(context.Background(), 1*time.Hour) 
 		defer gcCancel()
-		runGlobalGC(gcCtx, discoveryWatcher, globalCfgForWriterAndOthers, globalRetentionPeriod, gcDryRun)
+		runGlobalGC(gcCtx, discoveryWatcher, globalCfgForWriterAndOthers, globalRetentionPeriod, gcDryRun, gcKeepSuccessful, gcSweepOrphans, webhookSender)
 	})
 	if err != nil {
 		logger.Log.Fatal("Failed to schedule nightly GC job", zap.Error(err))
@@ -312,6 +551,8 @@ func main() {
 		}
 	})
 
+	hmux.Handle("/metrics", promhttp.Handler())
+
 	hmux.HandleFunc("/metadata", func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 		defer cancel()
@@ -323,21 +564,18 @@ func main() {
 			return
 		}
 
-		// Get writer for the first available container (for testing)
+		// Read the object through the writer of the container that produced it. Backups
+		// of removed containers are read from the default destination, or from dest.
 		registry := discoveryWatcher.GetRegistry()
-		if len(registry) == 0 {
-			http.Error(w, "no containers found", http.StatusNotFound)
-			return
+		spec, ok := catalog.ResolveSpec(registry, objectName)
+		if !ok {
+			spec = model.BackupSpec{Dest: "local"}
 		}
-
-		// Use the first container's spec to get writer
-		var firstSpec model.BackupSpec
-		for _, spec := range registry {
-			firstSpec = spec
-			break
+		if dest := r.URL.Query().Get("dest"); dest != "" {
+			spec.Dest = dest
 		}
 
-		backupWriter, err := writer.GetWriter(firstSpec, globalCfgForWriterAndOthers)
+		backupWriter, err := writer.GetWriter(spec, globalCfgForWriterAndOthers)
 		if err != nil {
 			http.Error(w, fmt.Sprintf("failed to get writer: %v", err), http.StatusInternalServerError)
 			return
@@ -354,6 +592,200 @@ func main() {
 		json.NewEncoder(w).Encode(metadata)
 	})
 
+	hmux.HandleFunc("/backups", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+		defer cancel()
+
+		query := r.URL.Query()
+		filter := catalog.Filter{
+			Container: query.Get("container"),
+			Type:      query.Get("type"),
+			Dest:      query.Get("dest"),
+		}
+		var err error
+		if filter.Since, err = parseCatalogTime(query.Get("since")); err != nil {
+			http.Error(w, fmt.Sprintf("invalid since: %v", err), http.StatusBadRequest)
+			return
+		}
+		if filter.Until, err = parseCatalogTime(query.Get("until")); err != nil {
+			http.Error(w, fmt.Sprintf("invalid until: %v", err), http.StatusBadRequest)
+			return
+		}
+		if v := query.Get("limit"); v != "" {
+			if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit < 0 {
+				http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+		}
+		if v := query.Get("offset"); v != "" {
+			if filter.Offset, err = strconv.Atoi(v); err != nil || filter.Offset < 0 {
+				http.Error(w, "offset must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+		}
+
+		page, err := catalog.List(ctx, discoveryWatcher.GetRegistry(), globalCfgForWriterAndOthers, filter)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to list backups: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	})
+
+	hmux.HandleFunc("/restore", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !authorizeAPI(w, r, restoreAPIToken, EnvRestoreAPIToken) {
+			return
+		}
+
+		var req restorer.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if req.Object == "" || req.Target == "" {
+			http.Error(w, "object and target are required", http.StatusBadRequest)
+			return
+		}
+
+		logger.Log.Info("Restore requested via API", zap.String("object", req.Object), zap.String("dest", req.Dest))
+		result, err := restorer.RestoreObject(r.Context(), req, globalCfgForWriterAndOthers)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("restore failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	})
+
+	hmux.HandleFunc("/backups/{container}/run", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !authorizeAPI(w, r, apiToken, EnvAPIToken) {
+			return
+		}
+
+		containerID, spec, ok := discoveryWatcher.FindSpec(r.PathValue("container"))
+		if !ok {
+			http.Error(w, "no unique container with backups enabled matches that ID or name", http.StatusNotFound)
+			return
+		}
+
+		current := sched.Load()
+		job, err := current.RunNow(containerID, spec)
+		if errors.Is(err, scheduler.ErrConcurrencyLimit) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		} else if errors.Is(err, scheduler.ErrJobRunning) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		} else if err != nil {
+			http.Error(w, fmt.Sprintf("failed to start backup: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		status := http.StatusAccepted
+		if wait, _ := strconv.ParseBool(r.URL.Query().Get("wait")); wait {
+			job, _ = current.WaitJob(r.Context(), job.ID)
+			if job.Status != scheduler.JobStatusRunning {
+				status = http.StatusOK
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/jobs/"+job.ID)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(job)
+	})
+
+	hmux.HandleFunc("/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// Recent on-demand jobs carry their full result; everything else comes from history
+		if job, ok := sched.Load().GetJob(r.PathValue("id")); ok {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(job)
+			return
+		}
+		if jobHistory == nil {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return
+		}
+		record, ok, err := jobHistory.Get(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(record)
+	})
+
+	hmux.HandleFunc("/queue", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sched.Load().QueueStatus())
+	})
+
+	hmux.HandleFunc("/jobs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if jobHistory == nil {
+			http.Error(w, fmt.Sprintf("job history is disabled, set %s to enable it", EnvStateDir), http.StatusNotFound)
+			return
+		}
+
+		query := r.URL.Query()
+		filter := jobstore.ListFilter{
+			Container: query.Get("container"),
+			Status:    query.Get("status"),
+			Trigger:   query.Get("trigger"),
+		}
+		if v := query.Get("limit"); v != "" {
+			limit, err := strconv.Atoi(v)
+			if err != nil || limit < 0 {
+				http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			filter.Limit = limit
+		}
+
+		records, err := jobHistory.List(filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"jobs": records})
+	})
+
 
 	server := &http.Server{
 		Addr:    ":8080",
@@ -410,18 +842,21 @@ Loop:
 		case <-reconcileTicker.C:
 			logger.Log.Debug("Reconciling scheduler jobs with discovered containers...")
 			currentRegistry := discoveryWatcher.GetRegistry()
-			activeScheduledJobs := sched.GetActiveJobsCount()
+			activeScheduledJobs := sched.Load().GetActiveJobsCount()
 			logger.Log.Debug("Reconciliation check", zap.Int("discoveredSpecs", len(currentRegistry)), zap.Int("activeJobs", activeScheduledJobs))
 
 			for id, spec := range currentRegistry {
 				if spec.Enabled {
-					if err := sched.AddOrUpdateJob(id, spec); err != nil {
+					if err := sched.Load().AddOrUpdateJob(id, spec); err != nil {
 						logger.Log.Error("Error scheduling job for container", zap.String("containerID", id), zap.Error(err))
 					}
 				} else {
-					sched.RemoveJob(id) 
+					sched.Load().RemoveJob(id) 
 				}
 			}
+			for _, id := range discoveryWatcher.TakeRemoved() {
+				sched.Load().RemoveJob(id)
+			}
 
 		case sig := <-sigChan:
 			switch sig {
@@ -450,11 +885,15 @@ Loop:
 					
 					webhookSender.Stop()
 					
-					sched.Stop()
-					
 					webhookSender = webhook.NewSender(newConfig)
 					
-					sched = scheduler.NewScheduler(newConfig, webhookSender, discoveryWatcher)
+					// The new scheduler is published before the old one stops, and takes
+					// over its on-demand jobs so their IDs keep resolving
+					previous := sched.Load()
+					next := scheduler.NewScheduler(newConfig, webhookSender, discoveryWatcher, jobHistory)
+					next.KeepJobs(previous)
+					sched.Store(next)
+					previous.Stop()
 					
 					logger.Log.Info("Components updated successfully with new configuration")
 				}
@@ -472,6 +911,6 @@ Loop:
 
 	logger.Log.Info("Cleaning up components...")
 	webhookSender.Stop()
-	sched.Stop()
+	sched.Load().Stop()
 	logger.Log.Info("Label Backup Agent stopped.")
 } 
\ No newline at end of file